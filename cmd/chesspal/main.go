@@ -6,6 +6,7 @@ import (
 	"flag"
 	"fmt"
 	"image/color"
+	"io"
 	"io/fs"
 	"io/ioutil"
 	"log"
@@ -13,6 +14,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,9 +23,11 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/notnil/chess"
 	"github.com/notnil/chess/image"
+	"github.com/windler/chesspal/pkg/dgt"
 	"github.com/windler/chesspal/pkg/eval"
 	"github.com/windler/chesspal/pkg/game"
 	"github.com/windler/chesspal/pkg/player"
+	"github.com/windler/chesspal/pkg/session"
 	"github.com/windler/chesspal/pkg/ui"
 	"github.com/windler/chesspal/pkg/util"
 	"gopkg.in/yaml.v3"
@@ -32,28 +36,61 @@ import (
 var upgrader = websocket.Upgrader{}
 
 const (
-	MSG_START        string = "start"
-	MSG_UNDO_N_MOVES string = "undo"
-	MSG_SET_RESULT   string = "result"
+	MSG_START           string = "start"
+	MSG_TAKEBACK        string = "takeback"
+	MSG_TAKEBACK_ANSWER string = "takeback_answer"
+	MSG_DRAW_OFFER      string = "draw_offer"
+	MSG_DRAW_ANSWER     string = "draw_answer"
+	MSG_SET_RESULT      string = "result"
+	MSG_RESUME          string = "resume"
+	MSG_PAUSE           string = "pause"
+	MSG_CONTINUE        string = "continue"
+	MSG_ABORT           string = "abort"
+	MSG_SUBSCRIBE       string = "subscribe"
+	MSG_MOVE            string = "move"
+	MSG_PROMOTE         string = "promote"
 )
 
 type Message struct {
-	Action    string       `json:"action"`
-	Options   StartOptions `json:"startOptions"`
-	UndoMoves int          `json:"undoMoves"`
-	Result    string       `json:"result"`
+	Action  string       `json:"action"`
+	Options StartOptions `json:"startOptions"`
+	Result  string       `json:"result"`
+	GameID  string       `json:"gameId"`
+	Claim   string       `json:"claim"`
+	Discard bool         `json:"discard"`
+	// SessionID is the session the message is meant for. The session watched by the client is used if it is empty.
+	SessionID string `json:"sessionId"`
+	// Move is a move in UCI notation entered in the browser
+	Move string `json:"move"`
+	// Color is the color requesting a takeback or offering a draw. It is determined by the game if it is empty.
+	Color  string `json:"color"`
+	Accept bool   `json:"accept"`
+	// Piece is the piece a pawn on the board is promoted to, e.g. "q"
+	Piece string `json:"piece"`
 }
 
 type StartOptions struct {
-	White      Player `json:"white"`
-	Black      Player `json:"black"`
-	EvalMode   int    `json:"evalMode"`
-	UpsideDown bool   `json:"upsideDown"`
+	White       Player `json:"white"`
+	Black       Player `json:"black"`
+	EvalMode    int    `json:"evalMode"`
+	UpsideDown  bool   `json:"upsideDown"`
+	TimeControl string `json:"timeControl"`
+	ClockMode   string `json:"clockMode"`
+	SideToMove  string `json:"sideToMove"`
+	Variant     string `json:"variant"`
+	// Chess960Position is the start position number. A random position is used if it is not set.
+	Chess960Position *int   `json:"chess960Position"`
+	Handicap         string `json:"handicap"`
+	HandicapGiver    string `json:"handicapGiver"`
+	// TakebackLimit overrides the number of takebacks per game of the config
+	TakebackLimit *int `json:"takebackLimit"`
 }
 
 type Player struct {
 	IsHuman bool `json:"isHuman"`
 	Type    int  `json:"type"`
+	// Browser is set for humans entering their moves in the browser instead of on the board
+	Browser bool `json:"browser"`
 }
 
 type Config struct {
@@ -61,12 +98,28 @@ type Config struct {
 	Web         string `yaml:"web"`
 	GamesFolder string `yaml:"gamesFolder"`
 	// ArchiveFolder string              `yaml:"archiveFolder"`
-	DgtPort string              `yaml:"dgtPort"`
+	DgtPort string `yaml:"dgtPort"`
+	// DgtUsbID is the USB id of the board, e.g. "045b:81b0". If it is set, the port of the board is looked up by it.
+	DgtUsbID string `yaml:"dgtUsbId"`
+	// DgtCapture is a file every byte read from and written to the board is appended to
+	DgtCapture string `yaml:"dgtCapture"`
+	// DgtReplay is a capture that is replayed instead of connecting to the board
+	DgtReplay string `yaml:"dgtReplay"`
+	// DgtSettleMs is the time the board has to be unchanged before a move is detected, 200ms by default
+	DgtSettleMs int `yaml:"dgtSettleMs"`
+	// Board is the driver of the board: "usb" (default) for DGT e-Boards, "pegasus", "millennium" or "certabo"
+	Board   string              `yaml:"board"`
 	Engines map[string]string   `yaml:"engines"`
 	Bots    []player.BotOptions `yaml:"bots"`
 	Humans  []Human             `yaml:"humans"`
 	Eval    Eval                `yaml:"eval"`
 	RClone  Rclone              `yaml:"rclone"`
+	// SubstituteBot is the name of the bot that takes over if a bot keeps failing during a game
+	SubstituteBot string `yaml:"substituteBot"`
+	// TakebackLimit is the number of takebacks per game. Takebacks are unlimited if it is not set.
+	TakebackLimit *int `yaml:"takebackLimit"`
+	// BoardTakebacks defines what happens if pieces are reverted to an earlier position on the board: "offer" (default), "auto" or "off"
+	BoardTakebacks string `yaml:"boardTakebacks"`
 }
 
 type Human struct {
@@ -86,31 +139,48 @@ type Eval struct {
 	Options    []string `yaml:"options"`
 }
 
-var started = false
-var g *game.Game
-var engine *player.DGTEngine
-var currentBoard chess.Board
+var engine player.ElectronicBoard
+var sessions *session.Manager
 
 type WSResponse struct {
 	Bots   []player.BotOptions `json:"bots"`
 	Humans []Human             `json:"humans"`
 }
 
+type SessionResponse struct {
+	SessionID string `json:"sessionId"`
+}
+
+// PromotionResponse asks to choose the piece a pawn on the given square is promoted to. The choice is no longer needed if it is empty.
+type PromotionResponse struct {
+	Promotion *string `json:"promotion"`
+}
+
+type DevicesResponse struct {
+	Devices []player.DeviceInfo `json:"devices"`
+}
+
+type SessionsResponse struct {
+	Sessions []session.Info `json:"sessions"`
+}
+
 type GameHistory struct {
 	Games []Game `json:"games"`
 }
 
 type Game struct {
-	ID       string `json:"id"`
-	PGN      string `json:"pgn"`
-	SVG      string `json:"svg"`
-	White    string `json:"white"`
-	Black    string `json:"black"`
-	Date     string `json:"date"`
-	DateTime int64  `json:"dateTime"`
-	Result   string `json:"result"`
-	Archived bool   `json:"archived"`
-	Botgame  bool   `json:"botgame"`
+	ID        string `json:"id"`
+	PGN       string `json:"pgn"`
+	SVG       string `json:"svg"`
+	White     string `json:"white"`
+	Black     string `json:"black"`
+	Date      string `json:"date"`
+	DateTime  int64  `json:"dateTime"`
+	Result    string `json:"result"`
+	Archived  bool   `json:"archived"`
+	Botgame   bool   `json:"botgame"`
+	Resumable bool   `json:"resumable"`
+	Recovered bool   `json:"recovered"`
 }
 
 var yellow = color.RGBA{255, 255, 0, 1}
@@ -135,29 +205,85 @@ func main() {
 
 	rcloneAll(*config, true)
 
-	wsUI := ui.NewWS()
+	journals, err := game.FindJournals(config.GamesFolder)
+	if err != nil {
+		log.Println(err)
+	}
+	for _, journal := range journals {
+		log.Printf("Found recoverable game %s", journal)
+	}
 
-	engine = player.NewDGTEngine()
-	go func() {
-		for true {
-			err := engine.Start(config.DgtPort)
+	boardEngine := player.NewBoardEngine()
+	if err := setBoardDriver(boardEngine, *config); err != nil {
+		log.Fatal(err)
+	}
+	if config.DgtCapture != "" {
+		capture, err := os.OpenFile(config.DgtCapture, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer capture.Close()
+		boardEngine.SetCapture(dgt.NewRecorder(capture))
+		log.Printf("Capturing the board to %s", config.DgtCapture)
+	}
+	e := newServer(config, boardEngine)
+	e.Logger.Fatal(e.Start(config.Address))
+}
 
-			if err == nil {
-				break
+// newServer connects the board and returns the server of the web UI and its websocket
+func newServer(config *Config, boardEngine *player.BoardEngine) *echo.Echo {
+	sessions = session.NewManager(ui.NewWS())
+	engine = boardEngine
+	go func() {
+		for device := range engine.DeviceChannel() {
+			sessions.Broadcast(&DevicesResponse{Devices: []player.DeviceInfo{device}})
+		}
+	}()
+	if config.DgtSettleMs > 0 {
+		boardEngine.SetSettleTime(time.Duration(config.DgtSettleMs) * time.Millisecond)
+	}
+	var simulator *dgt.Simulator
+	usbID := config.DgtUsbID
+	if config.DgtPort == dgt.SIMULATOR_PORT {
+		// the simulator speaks the protocol of the configured board
+		simulator = dgt.NewSimulator()
+		boardEngine.Simulate(simulator)
+		usbID = ""
+	}
+	if config.DgtReplay != "" {
+		if err := replayBoard(boardEngine, config.DgtReplay); err != nil {
+			log.Fatal(err)
+		}
+		usbID = ""
+	}
+	go engine.Connect(config.DgtPort, usbID)
+	go func() {
+		for board := range engine.PostionChannel() {
+			sessions.SetBoard(board)
+			if s := sessions.BoardSession(); s != nil && s.Game() != nil {
+				s.Game().UpdateBoard(board)
 			}
-			log.Println(err.Error())
-			time.Sleep(1 * time.Second)
 		}
-
-		go func() {
-			for board := range engine.PostionChannel() {
-				if !started {
-					currentBoard = board
-					wsUI.SendBoard(board)
+	}()
+	go func() {
+		for square := range engine.PromotionChannel() {
+			if s := sessions.BoardSession(); s != nil {
+				promotion := ""
+				if square != chess.NoSquare {
+					promotion = square.String()
 				}
+				s.UI().Send(&PromotionResponse{Promotion: &promotion})
 			}
-		}()
-		engine.ReadCurrentPosition()
+		}
+	}()
+	go func() {
+		for button := range engine.ButtonChannel() {
+			if s := sessions.BoardSession(); s != nil && s.Game() != nil {
+				if err := clockButtonPressed(s, button); err != nil {
+					sessionError(s, err)
+				}
+			}
+		}
 	}()
 
 	e := echo.New()
@@ -173,6 +299,9 @@ func main() {
 
 	e.DELETE("/history/:id", func(c echo.Context) error {
 		file := c.Param("id")
+		if sessions.RunningGame(gameIDFromFile(file)) {
+			return echo.NewHTTPError(http.StatusConflict, session.ErrGameInUse.Error())
+		}
 
 		err := os.Remove(fmt.Sprintf("%s%s", config.GamesFolder, file))
 		if err != nil {
@@ -202,6 +331,31 @@ func main() {
 	// 	return nil
 	// })
 
+	e.POST("/history/:id/resume", func(c echo.Context) error {
+		options := StartOptions{}
+		if err := c.Bind(&options); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		s, err := resumeGame(c.Param("id"), options, *config)
+		if err == session.ErrGameInUse {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		return c.JSON(http.StatusAccepted, SessionResponse{SessionID: s.ID()})
+	})
+
+	if simulator != nil {
+		registerSimulator(e, simulator)
+	}
+
+	e.GET("/devices", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, DevicesResponse{Devices: []player.DeviceInfo{engine.DeviceInfo()}})
+	})
+
 	e.GET("/history", func(c echo.Context) error {
 		files, err := ioutil.ReadDir(config.GamesFolder)
 		if err != nil {
@@ -210,6 +364,10 @@ func main() {
 
 		games := []Game{}
 		for _, file := range files {
+			if sessions.RunningGame(gameIDFromFile(file.Name())) {
+				// running games are watched in their session
+				continue
+			}
 			g := getGame(file, *config, false)
 			if g != nil {
 				games = append(games, *g)
@@ -240,18 +398,19 @@ func main() {
 		if !errors.Is(err, nil) {
 			log.Println(err)
 		}
+		client := ui.NewClient(ws)
 		defer func() {
-			wsUI.RemoveWebsocket(ws)
+			sessions.Disconnect(client)
 			ws.Close()
 		}()
 
-		if err := ws.WriteJSON(WSResponse{Bots: config.Bots, Humans: config.Humans}); !errors.Is(err, nil) {
-			log.Printf("error occurred: %v", err)
-		}
+		client.Send(WSResponse{Bots: config.Bots, Humans: config.Humans})
+		client.Send(SessionsResponse{Sessions: sessions.Sessions()})
+		client.Send(DevicesResponse{Devices: []player.DeviceInfo{engine.DeviceInfo()}})
 
-		wsUI.AddWebsocket(ws)
-		if started {
-			sendGameStarted(ws)
+		sessions.Connect(client)
+		if s := sessions.BoardSession(); s != nil {
+			subscribe(client, s)
 		}
 
 		for {
@@ -264,35 +423,44 @@ func main() {
 
 			switch msg.Action {
 			case MSG_START:
-				if !started {
-					go startGame(msg, wsUI, *config, ws)
-					started = true
+				startSession(client, msg, *config)
+
+			case MSG_RESUME:
+				s, err := resumeGame(msg.GameID, msg.Options, *config)
+				if err != nil {
+					log.Println(err)
+					sendError(client, err)
+					break
 				}
+				subscribe(client, s)
 
-			case MSG_UNDO_N_MOVES:
-				if msg.UndoMoves > 0 {
-					g.UndoMoves(msg.UndoMoves)
+			case MSG_SUBSCRIBE:
+				s := sessions.Get(msg.SessionID)
+				if s == nil {
+					sendError(client, fmt.Errorf("unknown session %s", msg.SessionID))
+					break
 				}
-			case MSG_SET_RESULT:
-				switch msg.Result {
-				case "draw":
-					g.Draw()
-				case "resign":
-					g.Resign()
+				subscribe(client, s)
+
+			default:
+				s := messageSession(client, msg)
+				if s == nil {
+					sendError(client, errors.New("no game running"))
+					break
 				}
+				handleGameMessage(client, s, msg)
 			}
-
 		}
 
 		return nil
 	})
 
-	e.Logger.Fatal(e.Start(config.Address))
-
+	return e
 }
 
 func getGame(f fs.FileInfo, config Config, archive bool) *Game {
-	if !strings.HasSuffix(f.Name(), "pgn") {
+	recovered := strings.HasSuffix(f.Name(), game.JOURNAL_SUFFIX)
+	if !strings.HasSuffix(f.Name(), "pgn") && !recovered {
 		return nil
 	}
 
@@ -313,9 +481,12 @@ func getGame(f fs.FileInfo, config Config, archive bool) *Game {
 	}
 	g := chess.NewGame(pgn)
 
-	lastMove := g.Moves()[len(g.Moves())-1]
-	mark := image.MarkSquares(yellow, lastMove.S1(), lastMove.S2())
-	svg := util.GetSVG(*g.Position().Board(), mark)
+	svg := util.GetSVG(*g.Position().Board())
+	if len(g.Moves()) > 0 {
+		lastMove := g.Moves()[len(g.Moves())-1]
+		mark := image.MarkSquares(yellow, lastMove.S1(), lastMove.S2())
+		svg = util.GetSVG(*g.Position().Board(), mark)
+	}
 
 	botGame := false
 
@@ -333,81 +504,727 @@ func getGame(f fs.FileInfo, config Config, archive bool) *Game {
 		dateTime = time.UnixMilli()
 	}
 	return &Game{
-		ID:       f.Name(),
-		PGN:      g.String(),
-		SVG:      svg,
-		White:    g.GetTagPair("White").Value,
-		Black:    g.GetTagPair("Black").Value,
-		Result:   string(g.Outcome()),
-		Date:     date,
-		DateTime: dateTime,
-		Archived: archive,
-		Botgame:  botGame,
+		ID:        f.Name(),
+		PGN:       g.String(),
+		SVG:       svg,
+		White:     g.GetTagPair("White").Value,
+		Black:     g.GetTagPair("Black").Value,
+		Result:    string(g.Outcome()),
+		Date:      date,
+		DateTime:  dateTime,
+		Archived:  archive,
+		Botgame:   botGame,
+		Resumable: g.Outcome() == chess.NoOutcome,
+		Recovered: recovered,
+	}
+}
+
+// setBoardDriver sets the driver of the configured board
+func setBoardDriver(engine *player.BoardEngine, config Config) error {
+	switch config.Board {
+	case "", "usb":
+		return nil
+	case "pegasus":
+		engine.SetDriver(player.PegasusDriver{})
+		return nil
+	case "millennium":
+		engine.SetDriver(player.MillenniumDriver{})
+		return nil
+	case "certabo":
+		engine.SetDriver(player.NewCertaboDriver())
+		return nil
+	}
+	return fmt.Errorf("unknown board %s", config.Board)
+}
+
+// replayBoard replays a capture of the board once instead of connecting to the board
+func replayBoard(engine *player.BoardEngine, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
 	}
+	defer f.Close()
+
+	entries, err := dgt.ReadCapture(f)
+	if err != nil {
+		return err
+	}
+
+	replayed := false
+	engine.SetOpener(func(string) (io.ReadWriteCloser, error) {
+		if replayed {
+			return nil, dgt.ErrReplayFinished
+		}
+		replayed = true
+		return dgt.NewReplay(entries), nil
+	})
+	log.Printf("Replaying the board from %s", file)
+
+	return nil
+}
+
+// SimulatorRequest lifts or drops a piece on the simulated board
+type SimulatorRequest struct {
+	Square string `json:"square"`
+	// Piece is the FEN character of the dropped piece, e.g. "Q" for a white queen
+	Piece string `json:"piece"`
+}
+
+// registerSimulator adds endpoints to play on the simulated board
+func registerSimulator(e *echo.Echo, simulator *dgt.Simulator) {
+	log.Println("Using the simulated board")
+
+	e.POST("/simulator/lift", func(c echo.Context) error {
+		req := SimulatorRequest{}
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		sq, err := parseSquare(req.Square)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		simulator.Lift(sq)
+		return c.NoContent(http.StatusOK)
+	})
+
+	e.POST("/simulator/drop", func(c echo.Context) error {
+		req := SimulatorRequest{}
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		sq, err := parseSquare(req.Square)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		piece, err := parsePiece(req.Piece)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		simulator.Drop(sq, piece)
+		return c.NoContent(http.StatusOK)
+	})
+
+	e.POST("/simulator/setup", func(c echo.Context) error {
+		simulator.SetBoard(*chess.NewGame().Position().Board())
+		return c.NoContent(http.StatusOK)
+	})
+
+	e.POST("/simulator/pgn", func(c echo.Context) error {
+		delay := time.Second
+		if ms, err := strconv.Atoi(c.QueryParam("delayMs")); err == nil {
+			delay = time.Duration(ms) * time.Millisecond
+		}
+
+		pgn, err := ioutil.ReadAll(c.Request().Body)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		go func() {
+			if err := simulator.PlayPGN(bytes.NewReader(pgn), delay); err != nil {
+				log.Println(err)
+			}
+		}()
+		return c.NoContent(http.StatusAccepted)
+	})
+}
+
+func parseSquare(s string) (chess.Square, error) {
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		if sq.String() == s {
+			return sq, nil
+		}
+	}
+	return chess.NoSquare, fmt.Errorf("invalid square %s", s)
+}
+
+// parsePiece parses the FEN character of a piece
+func parsePiece(s string) (chess.Piece, error) {
+	board, err := chess.FEN(fmt.Sprintf("%s7/8/8/8/8/8/8/8 w - - 0 1", s))
+	if err != nil || len(s) != 1 {
+		return chess.NoPiece, fmt.Errorf("invalid piece %s", s)
+	}
+	return chess.NewGame(board).Position().Board().Piece(chess.A8), nil
 }
 
 type Started struct {
 	Started bool `json:"started"`
 }
 
-func sendGameStarted(ws *websocket.Conn) {
-	ws.SetWriteDeadline(time.Now().Add(time.Second * 5))
+func sendGameStarted(client *ui.Client) {
+	client.Send(&Started{Started: true})
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func sendError(client *ui.Client, err error) {
+	client.Send(&ErrorResponse{Error: err.Error()})
+}
+
+// handleGameError sends errors of game actions to the client that triggered them
+func handleGameError(client *ui.Client, err error) {
+	if err != nil {
+		log.Println(err)
+		sendError(client, err)
+	}
+}
 
-	if err := ws.WriteJSON(&Started{Started: true}); !errors.Is(err, nil) {
-		log.Printf("error occurred: %v", err)
+// subscribe lets a client watch a session and tells it the session id
+func subscribe(client *ui.Client, s *session.Session) {
+	sessions.Subscribe(client, s)
+	client.Send(&SessionResponse{SessionID: s.ID()})
+	if s.Game() != nil {
+		sendGameStarted(client)
 	}
 }
 
-func startGame(msg *Message, ui *ui.WSUI, cfg Config, ws *websocket.Conn) {
-	evals := []game.EvalEngine{}
+// messageSession returns the session a message is meant for
+func messageSession(client *ui.Client, msg *Message) *session.Session {
+	if msg.SessionID != "" {
+		return sessions.Get(msg.SessionID)
+	}
+	return sessions.Subscription(client)
+}
+
+func handleGameMessage(client *ui.Client, s *session.Session, msg *Message) {
+	if msg.Action == MSG_ABORT {
+		handleGameError(client, s.Abort(msg.Discard))
+		return
+	}
+
+	g := s.Game()
+	if g == nil {
+		sendError(client, errors.New("game has not started yet"))
+		return
+	}
+
+	switch msg.Action {
+	case MSG_PAUSE:
+		handleGameError(client, g.Pause())
+	case MSG_CONTINUE:
+		handleGameError(client, g.Continue())
+	case MSG_MOVE:
+		handleGameError(client, browserMove(s, msg.Move))
+	case MSG_PROMOTE:
+		if !s.UsesBoard() {
+			sendError(client, player.ErrNoPromotion)
+			break
+		}
+		handleGameError(client, engine.Promote(promotionPiece(msg.Piece)))
+	case MSG_TAKEBACK:
+		by := chess.NoColor
+		if msg.Color != "" {
+			by = colorFromString(msg.Color)
+		}
+		handleGameError(client, g.RequestTakeback(by))
+	case MSG_TAKEBACK_ANSWER:
+		handleGameError(client, g.AnswerTakeback(msg.Accept))
+	case MSG_DRAW_OFFER:
+		by := chess.NoColor
+		if msg.Color != "" {
+			by = colorFromString(msg.Color)
+		}
+		handleGameError(client, g.OfferDraw(by))
+	case MSG_DRAW_ANSWER:
+		handleGameError(client, g.AnswerDraw(msg.Accept))
+	case MSG_SET_RESULT:
+		switch msg.Result {
+		case "resign":
+			handleGameError(client, g.Resign())
+		case "claim":
+			handleGameError(client, g.ClaimDraw(drawClaimMethod(msg.Claim)))
+		}
+	}
+}
+
+// browserMove passes a move entered in the browser to the browser player to move
+func browserMove(s *session.Session, move string) error {
+	err := player.ErrNotYourTurn
+	for _, p := range s.Players() {
+		if browser, ok := p.(*player.Browser); ok {
+			err = browser.Move(move)
+			if err != player.ErrNotYourTurn {
+				return err
+			}
+		}
+	}
+
+	return err
+}
+
+// usesBoard returns true if one of the players plays on the board
+func usesBoard(players ...Player) bool {
+	for _, p := range players {
+		if p.IsHuman && !p.Browser {
+			return true
+		}
+	}
+	return false
+}
+
+func startSession(client *ui.Client, msg *Message, cfg Config) {
+	s, err := sessions.Create(usesBoard(msg.Options.White, msg.Options.Black))
+	if err != nil {
+		log.Println(err)
+		sendError(client, err)
+		return
+	}
 
-	ui.Reset()
-	engine.Reset()
-	engine.SetUpsideDown(msg.Options.UpsideDown)
+	subscribe(client, s)
+	sessions.Broadcast(&SessionsResponse{Sessions: sessions.Sessions()})
+
+	go func() {
+		startGame(s, msg, cfg)
+		endSession(s)
+	}()
+}
+
+func endSession(s *session.Session) {
+	sessions.End(s)
+	sessions.Broadcast(&SessionsResponse{Sessions: sessions.Sessions()})
+}
+
+func sessionError(s *session.Session, err error) {
+	log.Println(err)
+	s.UI().Send(&ErrorResponse{Error: err.Error()})
+}
+
+func startGame(s *session.Session, msg *Message, cfg Config) {
+	if s.UsesBoard() {
+		setUpsideDown(msg.Options.UpsideDown)
+	}
+
+	fen, setup, err := startPosition(msg.Options, s.UsesBoard())
+	if err != nil {
+		sessionError(s, err)
+		return
+	}
+
+	var tc *game.TimeControl
+	if msg.Options.TimeControl != "" {
+		tc, err = game.ParseTimeControl(msg.Options.TimeControl, game.ClockMode(msg.Options.ClockMode))
+		if err != nil {
+			sessionError(s, err)
+			return
+		}
+	}
+
+	s.UI().Reset()
+	if s.UsesBoard() {
+		engine.Reset()
+	}
 
 	log.Printf("Black: %+v, White: %+v", msg.Options.Black, msg.Options.White)
 
-	var white, black game.Player
-	if msg.Options.Black.IsHuman {
-		i := msg.Options.Black.Type
-		human := cfg.Humans[i]
-		black = player.NewDGTPlayer(human.Name, engine)
-	} else {
-		i := msg.Options.Black.Type
-		options := cfg.Bots[i]
-		options.Path = cfg.Engines[options.Engine]
-		black = player.NewUCIPlayer(options)
-	}
-	if msg.Options.White.IsHuman {
-		i := msg.Options.White.Type
-		human := cfg.Humans[i]
-		white = player.NewDGTPlayer(human.Name, engine)
-	} else {
-		i := msg.Options.White.Type
-		options := cfg.Bots[i]
-		options.Path = cfg.Engines[options.Engine]
-		white = player.NewUCIPlayer(options)
-	}
-
-	g = game.NewGame(black, white, ui)
-
-	if msg.Options.EvalMode == 1 {
+	white, err := newPlayer(msg.Options.White, cfg, msg.Options.Variant)
+	if err != nil {
+		sessionError(s, err)
+		return
+	}
+	black, err := newPlayer(msg.Options.Black, cfg, msg.Options.Variant)
+	if err != nil {
+		sessionError(s, err)
+		white.End()
+		return
+	}
+	s.SetPlayers(white, black)
+
+	if setup && s.UsesBoard() {
+		fenGame, _ := chess.FEN(fen)
+		if !waitForBoard(s, *chess.NewGame(fenGame).Position().Board()) {
+			white.End()
+			black.End()
+			s.UI().SendBoard(sessions.Board())
+			return
+		}
+	}
+
+	g := game.NewGame(black, white, s.UI())
+	if err := sessions.ClaimGame(s, g.ID()); err != nil {
+		log.Println(err)
+	}
+	g.EnableJournal(cfg.GamesFolder)
+	g.SetSubstitute(substituteBot(cfg, msg.Options.Variant))
+	enableBoard(s, g, cfg)
+	g.SetVariant(msg.Options.Variant)
+	if msg.Options.Handicap != "" {
+		g.SetHandicap(game.Handicap(msg.Options.Handicap), colorFromString(msg.Options.HandicapGiver))
+	}
+
+	if msg.Options.TakebackLimit != nil {
+		g.SetTakebackLimit(*msg.Options.TakebackLimit)
+	} else if cfg.TakebackLimit != nil {
+		g.SetTakebackLimit(*cfg.TakebackLimit)
+	}
+
+	if tc != nil {
+		g.SetTimeControl(*tc)
+	}
+
+	evals := newEvals(msg.Options.EvalMode, cfg, msg.Options.Variant)
+
+	s.SetGame(g)
+	s.UI().Send(&Started{Started: true})
+	if err := g.Start(fen, evals...); err != nil {
+		sessionError(s, err)
+		return
+	}
+	g.Save(cfg.GamesFolder)
+
+	rcloneAll(cfg, false)
+}
+
+// enableBoard lets the game of a session on the board follow the board
+func enableBoard(s *session.Session, g *game.Game, cfg Config) {
+	if !s.UsesBoard() {
+		return
+	}
+
+	mode := game.BoardTakebackMode(cfg.BoardTakebacks)
+	if mode == "" {
+		mode = game.BOARD_TAKEBACK_OFFER
+	}
+	g.EnableBoard(mode, sessions.Board())
+	g.Subscribe(ui.NewDGTClock(engine, engine.UpsideDown()))
+	g.Subscribe(ui.NewBoardLeds(engine))
+}
+
+func colorFromString(color string) chess.Color {
+	if color == chess.Black.String() {
+		return chess.Black
+	}
+	return chess.White
+}
+
+// startPosition returns the FEN to start a game with and whether the position has to be set up on the board first.
+// Games on the board start from the position on the board.
+func startPosition(options StartOptions, onBoard bool) (string, bool, error) {
+	if options.Variant == game.VARIANT_CHESS960 {
+		if options.Handicap != "" {
+			return "", false, errors.New("handicaps are not supported in Chess960 games")
+		}
+
+		position := game.RandomChess960Position()
+		if options.Chess960Position != nil {
+			position = *options.Chess960Position
+		}
+
+		fen, err := game.Chess960FEN(position)
+		if err != nil {
+			return "", false, err
+		}
+		log.Printf("Chess960 start position %d: %s", position, fen)
+
+		return fen, true, nil
+	}
+
+	if options.Handicap != "" {
+		fen, err := game.HandicapFEN(game.Handicap(options.Handicap), colorFromString(options.HandicapGiver))
+		return fen, true, err
+	}
+
+	board := sessions.Board()
+	if onBoard && len(board.SquareMap()) > 0 {
+		fen, err := game.FENFromBoard(board, colorFromString(options.SideToMove))
+		if err != nil {
+			return "", false, fmt.Errorf("invalid board setup: %w", err)
+		}
+
+		return fen, false, nil
+	}
+
+	return "", false, nil
+}
+
+// clockButtonPressed performs the game action of a button of the clock connected to the board. Resigning has to be
+// confirmed by pressing the button again within session.RESIGN_CONFIRM_TIME.
+func clockButtonPressed(s *session.Session, button player.ClockButton) error {
+	g := s.Game()
+	if button == player.CLOCK_BUTTON_NEXT {
+		if s.RequestResign(clockEnd) {
+			return g.Resign()
+		}
+		return engine.ClockText("resign?", true)
+	}
+	if s.CancelResign() {
+		clockEnd()
+	}
+
+	switch button {
+	case player.CLOCK_BUTTON_BACK:
+		return g.RequestTakeback(chess.NoColor)
+	case player.CLOCK_BUTTON_MINUS:
+		return g.OfferDraw(chess.NoColor)
+	case player.CLOCK_BUTTON_PLAY:
+		if err := g.Pause(); err != game.ErrGameNotRunning {
+			return err
+		}
+		return g.Continue()
+	case player.CLOCK_BUTTON_PLUS:
+		// a pending draw offer is answered first, otherwise a takeback
+		if err := g.AnswerDraw(true); err != game.ErrNoDrawOffer {
+			return err
+		}
+		return g.AnswerTakeback(true)
+	}
+
+	return nil
+}
+
+// clockEnd shows the times on the clock again after a text was displayed
+func clockEnd() {
+	if err := engine.ClockEnd(); err != nil {
+		log.Println(err)
+	}
+}
+
+func promotionPiece(piece string) chess.PieceType {
+	switch piece {
+	case chess.Rook.String():
+		return chess.Rook
+	case chess.Bishop.String():
+		return chess.Bishop
+	case chess.Knight.String():
+		return chess.Knight
+	}
+
+	return chess.Queen
+}
+
+func drawClaimMethod(claim string) chess.Method {
+	switch claim {
+	case chess.ThreefoldRepetition.String():
+		return chess.ThreefoldRepetition
+	case chess.FiftyMoveRule.String():
+		return chess.FiftyMoveRule
+	}
+
+	return chess.NoMethod
+}
+
+func setUpsideDown(upsideDown bool) {
+	if engine.UpsideDown() != upsideDown {
+		// the last board dump was read with the other orientation
+		board := sessions.Board()
+		sessions.SetBoard(*board.Flip(chess.UpDown).Flip(chess.LeftRight))
+	}
+	engine.SetUpsideDown(upsideDown)
+}
+
+func newPlayer(p Player, cfg Config, variant string) (game.Player, error) {
+	if p.IsHuman {
+		human := cfg.Humans[p.Type]
+		if p.Browser {
+			return player.NewBrowserPlayer(human.Name), nil
+		}
+		return player.NewBoardPlayer(human.Name, engine), nil
+	}
+
+	options := cfg.Bots[p.Type]
+	options.Path = cfg.Engines[options.Engine]
+	options.Options = variantOptions(options.Options, variant)
+
+	bot, err := player.NewUCIPlayer(options)
+	if err != nil {
+		return nil, fmt.Errorf("error creating bot %s: %w", options.Name, err)
+	}
+	return bot, nil
+}
+
+// substituteBot returns a factory for the configured substitute bot or nil if there is none
+func substituteBot(cfg Config, variant string) func(chess.Color) (game.Player, error) {
+	for i, bot := range cfg.Bots {
+		if bot.Name == cfg.SubstituteBot {
+			return func(chess.Color) (game.Player, error) {
+				return newPlayer(Player{IsHuman: false, Type: i}, cfg, variant)
+			}
+		}
+	}
+
+	return nil
+}
+
+// variantOptions returns a copy of UCI options including the options needed for the variant
+func variantOptions(options []string, variant string) []string {
+	result := append([]string{}, options...)
+	if variant == game.VARIANT_CHESS960 {
+		result = append(result, "UCI_Chess960=true")
+	}
+
+	return result
+}
+
+// playerByName creates the player for a name found in PGN tags. Unknown names are humans playing in the browser or on
+// the board by their saved type, games saved without the type were played on the board.
+func playerByName(name, playerType string, cfg Config, variant string) (game.Player, error) {
+	for i, bot := range cfg.Bots {
+		if bot.Name == name {
+			return newPlayer(Player{IsHuman: false, Type: i}, cfg, variant)
+		}
+	}
+
+	if playerType == player.PLAYER_TYPE_BROWSER {
+		return player.NewBrowserPlayer(name), nil
+	}
+	return player.NewBoardPlayer(name, engine), nil
+}
+
+// playsOnBoard returns true if the player of a color of a saved game is no bot and did not play in the browser
+func playsOnBoard(pgn *chess.Game, color chess.Color, cfg Config) bool {
+	return !isBotName(pgn.GetTagPair(color.Name()).Value, cfg) && game.PlayerType(pgn, color) != player.PLAYER_TYPE_BROWSER
+}
+
+func newEvals(evalMode int, cfg Config, variant string) []game.EvalEngine {
+	evals := []game.EvalEngine{}
+	if evalMode == 1 {
 		evals = append(evals, eval.NewLastMoveEval(
 			cfg.Engines[cfg.Eval.Engine],
-			cfg.Eval.Options,
+			variantOptions(cfg.Eval.Options, variant),
 			cfg.Eval.Threads,
 			cfg.Eval.Depth,
 			cfg.Eval.MoveTimeMs,
 		))
 	}
 
-	sendGameStarted(ws)
-	g.Start(currentBoard.String(), evals...)
-	g.Save(cfg.GamesFolder)
+	return evals
+}
 
-	rcloneAll(cfg, false)
+func loadGame(id string, cfg Config) (*chess.Game, error) {
+	contents, err := os.Open(fmt.Sprintf("%s%s", cfg.GamesFolder, filepath.Base(id)))
+	if err != nil {
+		return nil, err
+	}
+	defer contents.Close()
+
+	pgn, err := chess.PGN(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	return chess.NewGame(pgn), nil
+}
+
+// isBotName returns true if a bot with the given name is configured
+func isBotName(name string, cfg Config) bool {
+	for _, bot := range cfg.Bots {
+		if bot.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func resumeGame(id string, options StartOptions, cfg Config) (*session.Session, error) {
+	pgn, err := loadGame(id, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if pgn.Outcome() != chess.NoOutcome {
+		return nil, fmt.Errorf("game %s is already finished", id)
+	}
+
+	whiteName := pgn.GetTagPair("White").Value
+	blackName := pgn.GetTagPair("Black").Value
+
+	s, err := sessions.Create(playsOnBoard(pgn, chess.White, cfg) || playsOnBoard(pgn, chess.Black, cfg))
+	if err != nil {
+		return nil, err
+	}
+	gameID := gameIDFromFile(id)
+	if err := sessions.ClaimGame(s, gameID); err != nil {
+		sessions.End(s)
+		return nil, err
+	}
+	sessions.Broadcast(&SessionsResponse{Sessions: sessions.Sessions()})
+
+	go func() {
+		defer endSession(s)
+
+		if s.UsesBoard() {
+			setUpsideDown(options.UpsideDown)
+			engine.Reset()
+		}
+		s.UI().Reset()
+
+		variant := ""
+		if tag := pgn.GetTagPair("Variant"); tag != nil {
+			variant = tag.Value
+		}
+
+		white, err := playerByName(whiteName, game.PlayerType(pgn, chess.White), cfg, variant)
+		if err != nil {
+			sessionError(s, err)
+			return
+		}
+		black, err := playerByName(blackName, game.PlayerType(pgn, chess.Black), cfg, variant)
+		if err != nil {
+			sessionError(s, err)
+			white.End()
+			return
+		}
+		s.SetPlayers(white, black)
+
+		if s.UsesBoard() {
+			if !waitForBoard(s, *pgn.Position().Board()) {
+				white.End()
+				black.End()
+				s.UI().SendBoard(sessions.Board())
+				return
+			}
+		}
+
+		g := game.NewGame(black, white, s.UI())
+		g.EnableJournal(cfg.GamesFolder)
+		g.SetSubstitute(substituteBot(cfg, variant))
+		enableBoard(s, g, cfg)
+		s.SetGame(g)
+		s.UI().Send(&Started{Started: true})
+
+		if err := g.Resume(gameID, pgn, newEvals(options.EvalMode, cfg, variant)...); err != nil {
+			sessionError(s, err)
+			return
+		}
+		g.Save(cfg.GamesFolder)
+
+		rcloneAll(cfg, false)
+	}()
 
-	started = false
+	return s, nil
+}
+
+// gameIDFromFile returns the id of the game saved or journaled in a file
+func gameIDFromFile(file string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(filepath.Base(file), ".pgn"), game.JOURNAL_SUFFIX)
+}
+
+// waitForBoard blocks until the physical board matches the expected board and shows the differing squares meanwhile.
+// It returns false if the setup of the session was aborted.
+func waitForBoard(s *session.Session, expected chess.Board) bool {
+	ctx, cancel := s.SetupContext()
+	defer cancel()
+
+	lastDiff := -1
+	for {
+		if ctx.Err() != nil {
+			log.Println("Board setup aborted")
+			return false
+		}
+
+		diff := game.BoardDiff(expected, sessions.Board())
+		if len(diff) == 0 {
+			return true
+		}
+
+		if len(diff) != lastDiff {
+			s.UI().SendBoardDiff(expected, diff)
+			lastDiff = len(diff)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
 }
 
 func rcloneAll(cfg Config, download bool) {
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/windler/chesspal/pkg/dgt"
+	"github.com/windler/chesspal/pkg/player"
+)
+
+// response holds the fields of the websocket messages the test waits for
+type response struct {
+	Started bool                `json:"started"`
+	Devices []player.DeviceInfo `json:"devices"`
+	Moves   []struct{}          `json:"moves"`
+	Outcome string              `json:"outcome"`
+	Method  string              `json:"method"`
+	Error   string              `json:"error"`
+}
+
+// waitForResponse reads messages from the websocket until one matches
+func waitForResponse(t *testing.T, ws *websocket.Conn, match func(response) bool) response {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatalf("expected a matching message: %v", err)
+		}
+		res := response{}
+		if err := json.Unmarshal(data, &res); err != nil {
+			continue
+		}
+		if res.Error != "" {
+			t.Fatalf("unexpected error %s", res.Error)
+		}
+		if match(res) {
+			return res
+		}
+	}
+}
+
+// simulate posts a request to the endpoints of the simulated board
+func simulate(t *testing.T, server *httptest.Server, action, square, piece string) {
+	t.Helper()
+	body, _ := json.Marshal(SimulatorRequest{Square: square, Piece: piece})
+	res, err := http.Post(server.URL+"/simulator/"+action, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("%s %s failed with %d", action, square, res.StatusCode)
+	}
+}
+
+func TestBoardGame(t *testing.T) {
+	folder := t.TempDir() + "/"
+	config := &Config{
+		Web:         folder,
+		GamesFolder: folder,
+		DgtPort:     dgt.SIMULATOR_PORT,
+		DgtSettleMs: 10,
+		Humans:      []Human{{Name: "Alice"}, {Name: "Bob"}},
+	}
+	server := httptest.NewServer(newServer(config, player.NewBoardEngine()))
+	defer server.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	waitForResponse(t, ws, func(res response) bool {
+		return len(res.Devices) == 1 && res.Devices[0].Connected
+	})
+
+	ws.WriteJSON(Message{
+		Action: MSG_START,
+		Options: StartOptions{
+			White: Player{IsHuman: true, Type: 0},
+			Black: Player{IsHuman: true, Type: 1},
+		},
+	})
+	waitForResponse(t, ws, func(res response) bool {
+		return res.Started
+	})
+
+	moves := []struct {
+		from, to, piece string
+	}{
+		{"f2", "f3", "P"},
+		{"e7", "e5", "p"},
+		{"g2", "g4", "P"},
+		{"d8", "h4", "q"},
+	}
+	var last response
+	for i, move := range moves {
+		simulate(t, server, "lift", move.from, "")
+		simulate(t, server, "drop", move.to, move.piece)
+		last = waitForResponse(t, ws, func(res response) bool {
+			return len(res.Moves) == i+1
+		})
+	}
+
+	if last.Outcome != "0-1" || last.Method != "Checkmate" {
+		t.Errorf("expected black to mate, got %s by %s", last.Outcome, last.Method)
+	}
+}
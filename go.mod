@@ -11,4 +11,5 @@ require (
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
 
-replace github.com/notnil/chess => github.com/windler/chess v1.8.0
+// the chess library is vendored with Chess960 castling, see third_party/chess/chess960.go
+replace github.com/notnil/chess => ./third_party/chess
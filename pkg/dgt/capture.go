@@ -0,0 +1,218 @@
+package dgt
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrReplayFinished is returned when a replay is opened again after all its bytes were read
+var ErrReplayFinished = errors.New("replay finished")
+
+// CAPTURE_SESSION starts the line written when a recorder is created, followed by the time of day. Captures are
+// appended to across restarts and the times of each session start at 0 again.
+const CAPTURE_SESSION = "session"
+
+// CaptureEntry are bytes read from or written to the board
+type CaptureEntry struct {
+	// Time is the time since the capture was started
+	Time    time.Duration
+	Written bool
+	Data    []byte
+}
+
+// Recorder captures every byte read from and written to the board. Each line of a capture holds the milliseconds since
+// the session was started, "r" or "w" and the bytes in hex, e.g. "1520 w 42". Every recorder starts a new session with a
+// CAPTURE_SESSION line. Lines starting with "#" are comments.
+type Recorder struct {
+	mutex *sync.Mutex
+	w     io.Writer
+	start time.Time
+}
+
+// NewRecorder creates a recorder writing the capture to w and starts a new session
+func NewRecorder(w io.Writer) *Recorder {
+	r := &Recorder{
+		mutex: &sync.Mutex{},
+		w:     w,
+		start: time.Now(),
+	}
+	fmt.Fprintf(w, "%s %s\n", CAPTURE_SESSION, r.start.Format(time.RFC3339))
+
+	return r
+}
+
+// Wrap records the bytes of a connection to the board opened on the given port. The recorder is not closed with the
+// connection, so one capture can span several connections.
+func (r *Recorder) Wrap(port string, conn io.ReadWriteCloser) io.ReadWriteCloser {
+	r.comment(fmt.Sprintf("opened %s at %s", port, time.Now().Format(time.RFC3339)))
+	return &recordedConn{conn: conn, recorder: r}
+}
+
+func (r *Recorder) comment(text string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	fmt.Fprintf(r.w, "# %s\n", text)
+}
+
+func (r *Recorder) record(written bool, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	direction := "r"
+	if written {
+		direction = "w"
+	}
+	fmt.Fprintf(r.w, "%d %s %s\n", time.Since(r.start).Milliseconds(), direction, hex.EncodeToString(data))
+}
+
+type recordedConn struct {
+	conn     io.ReadWriteCloser
+	recorder *Recorder
+}
+
+func (c *recordedConn) Read(b []byte) (int, error) {
+	n, err := c.conn.Read(b)
+	c.recorder.record(false, b[:n])
+	return n, err
+}
+
+func (c *recordedConn) Write(b []byte) (int, error) {
+	n, err := c.conn.Write(b)
+	c.recorder.record(true, b[:n])
+	return n, err
+}
+
+func (c *recordedConn) Close() error {
+	c.recorder.comment("closed")
+	return c.conn.Close()
+}
+
+// ReadCapture parses a capture written by a Recorder. The times of later sessions continue after the last entry of the
+// previous session, so the entries of a capture spanning several sessions are in order.
+func ReadCapture(r io.Reader) ([]CaptureEntry, error) {
+	entries := []CaptureEntry{}
+	offset, last := time.Duration(0), time.Duration(0)
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if fields[0] == CAPTURE_SESSION {
+			offset = last
+			continue
+		}
+		if len(fields) != 3 || (fields[1] != "r" && fields[1] != "w") {
+			return nil, fmt.Errorf("invalid capture line %d: %s", line, text)
+		}
+		ms, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time in capture line %d: %v", line, err)
+		}
+		data, err := hex.DecodeString(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bytes in capture line %d: %v", line, err)
+		}
+
+		t := offset + time.Duration(ms)*time.Millisecond
+		if t < last {
+			// a session without a session line, e.g. of an older capture
+			offset = last
+			t = offset + time.Duration(ms)*time.Millisecond
+		}
+		last = t
+
+		entries = append(entries, CaptureEntry{
+			Time:    t,
+			Written: fields[1] == "w",
+			Data:    data,
+		})
+	}
+
+	return entries, scanner.Err()
+}
+
+// Replay plays the bytes read from the board in a capture with their original timing. It can be used instead of the
+// serial port of the board. Bytes written to it are discarded. Reading returns io.EOF once the capture is finished.
+type Replay struct {
+	entries []CaptureEntry
+	pending []byte
+	start   time.Time
+	closed  chan struct{}
+	once    *sync.Once
+}
+
+// NewReplay creates a replay of a capture. The timing starts with the first read.
+func NewReplay(entries []CaptureEntry) *Replay {
+	reads := []CaptureEntry{}
+	for _, entry := range entries {
+		if !entry.Written {
+			reads = append(reads, entry)
+		}
+	}
+
+	return &Replay{
+		entries: reads,
+		closed:  make(chan struct{}),
+		once:    &sync.Once{},
+	}
+}
+
+func (r *Replay) Read(b []byte) (int, error) {
+	if r.start.IsZero() {
+		r.start = time.Now()
+	}
+
+	for len(r.pending) == 0 {
+		if len(r.entries) == 0 {
+			return 0, io.EOF
+		}
+
+		entry := r.entries[0]
+		timer := time.NewTimer(time.Until(r.start.Add(entry.Time)))
+		select {
+		case <-timer.C:
+		case <-r.closed:
+			timer.Stop()
+			return 0, io.ErrClosedPipe
+		}
+
+		r.entries = r.entries[1:]
+		r.pending = entry.Data
+	}
+
+	n := copy(b, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+func (r *Replay) Write(b []byte) (int, error) {
+	select {
+	case <-r.closed:
+		return 0, io.ErrClosedPipe
+	default:
+		return len(b), nil
+	}
+}
+
+func (r *Replay) Close() error {
+	r.once.Do(func() {
+		close(r.closed)
+	})
+	return nil
+}
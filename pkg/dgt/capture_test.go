@@ -0,0 +1,98 @@
+package dgt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorderSessions(t *testing.T) {
+	var capture bytes.Buffer
+
+	// two runs of chesspal appending to the same capture
+	for i := 0; i < 2; i++ {
+		recorder := NewRecorder(&capture)
+		conn := recorder.Wrap("simulator", NewSimulator().Connect())
+		if _, err := conn.Write([]byte{DGT_SEND_BRD}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.Read(make([]byte, 67)); err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+	}
+
+	if sessions := strings.Count(capture.String(), CAPTURE_SESSION+" "); sessions != 2 {
+		t.Errorf("expected 2 sessions, got %d:\n%s", sessions, capture.String())
+	}
+
+	entries, err := ReadCapture(&capture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(entries))
+	}
+	if !entries[0].Written || entries[1].Written || entries[1].Data[0] != byte(DGT_MSG_BOARD_DUMP) {
+		t.Errorf("expected the written command and the board dump, got %+v", entries[:2])
+	}
+}
+
+func TestReadCaptureSessions(t *testing.T) {
+	capture := `session 2026-10-18T10:00:00Z
+# opened /dev/ttyACM0
+0 w 42
+500 r 8e00053400
+1500 r 8e00052401
+session 2026-10-18T10:05:00Z
+0 w 42
+200 r 8e00053400
+# an older session without a session line
+100 r 8e00052401
+`
+	entries, err := ReadCapture(strings.NewReader(capture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []time.Duration{0, 500, 1500, 1500, 1700, 1800}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, ms := range expected {
+		if entries[i].Time != ms*time.Millisecond {
+			t.Errorf("entry %d: expected %dms, got %s", i, ms, entries[i].Time)
+		}
+	}
+}
+
+func TestReadCaptureInvalid(t *testing.T) {
+	for _, capture := range []string{"0 x 42", "a r 42", "0 r zz", "0 r"} {
+		if _, err := ReadCapture(strings.NewReader(capture)); err == nil {
+			t.Errorf("%q: expected an error", capture)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	entries := []CaptureEntry{
+		{Time: 0, Written: true, Data: []byte{DGT_SEND_BRD}},
+		{Time: 10 * time.Millisecond, Data: []byte{1, 2}},
+		{Time: 30 * time.Millisecond, Data: []byte{3}},
+	}
+
+	replay := NewReplay(entries)
+	start := time.Now()
+	data, err := ioutil.ReadAll(replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, []byte{1, 2, 3}) {
+		t.Errorf("expected the read bytes only, got %v", data)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("expected the original timing, took %s", elapsed)
+	}
+}
@@ -0,0 +1,163 @@
+package dgt
+
+import (
+	"time"
+)
+
+// Commands of clock messages sent to a DGT 3000 connected to the board
+const (
+	DGT_CMD_CLOCK_END_MESSAGE   = 0x00
+	DGT_CMD_CLOCK_START_MESSAGE = 0x03
+	DGT_CMD_CLOCK_END           = 0x03
+	DGT_CMD_CLOCK_BUTTON        = 0x08
+	DGT_CMD_CLOCK_VERSION       = 0x09
+	DGT_CMD_CLOCK_SETNRUN       = 0x0a
+	DGT_CMD_CLOCK_BEEP          = 0x0b
+	DGT_CMD_CLOCK_ASCII         = 0x0c
+)
+
+// CLOCK_TEXT_SIZE is the number of characters the clock displays
+const CLOCK_TEXT_SIZE = 8
+
+type ClockSide byte
+
+const (
+	CLOCK_SIDE_LEFT  ClockSide = 0x01
+	CLOCK_SIDE_RIGHT ClockSide = 0x02
+	// CLOCK_SIDE_NONE stops both clocks
+	CLOCK_SIDE_NONE ClockSide = 0x04
+)
+
+// ClockButton is one of the five buttons of the clock, numbered from left to right
+type ClockButton int
+
+const (
+	CLOCK_BUTTON_NONE  ClockButton = -1
+	CLOCK_BUTTON_BACK  ClockButton = 0
+	CLOCK_BUTTON_MINUS ClockButton = 1
+	CLOCK_BUTTON_PLAY  ClockButton = 2
+	CLOCK_BUTTON_PLUS  ClockButton = 3
+	CLOCK_BUTTON_NEXT  ClockButton = 4
+)
+
+// clockAckButton is the acknowledgement a clock sends when a button was pressed
+const clockAckButton = 0x88
+
+var clockButtons = map[byte]ClockButton{
+	0x31: CLOCK_BUTTON_BACK,
+	0x32: CLOCK_BUTTON_MINUS,
+	0x33: CLOCK_BUTTON_PLAY,
+	0x34: CLOCK_BUTTON_PLUS,
+	0x35: CLOCK_BUTTON_NEXT,
+}
+
+// ClockState are the times shown by the clock
+type ClockState struct {
+	Left  time.Duration
+	Right time.Duration
+	// Running is the side whose time is running or CLOCK_SIDE_NONE
+	Running ClockSide
+	// Connected is false if no clock is connected to the board
+	Connected bool
+}
+
+// IsAck returns true if the message acknowledges a clock command or reports a button press instead of the times
+func (c ClockTime) IsAck() bool {
+	return c.Data[0]&0x0f == 0x0a || c.Data[3]&0x0f == 0x0a
+}
+
+// Button returns the pressed button of an acknowledgement or CLOCK_BUTTON_NONE
+func (c ClockTime) Button() ClockButton {
+	if !c.IsAck() {
+		return CLOCK_BUTTON_NONE
+	}
+
+	ack1 := c.Data[2]&0x7f | (c.Data[3]<<2)&0x80
+	ack3 := c.Data[5]&0x7f | (c.Data[0]<<2)&0x80
+	if ack1 != clockAckButton {
+		return CLOCK_BUTTON_NONE
+	}
+
+	if button, ok := clockButtons[ack3]; ok {
+		return button
+	}
+	return CLOCK_BUTTON_NONE
+}
+
+// State returns the times of a message that is no acknowledgement
+func (c ClockTime) State() ClockState {
+	status := c.Data[6] & 0x3f
+
+	state := ClockState{
+		Right:     clockDuration(c.Data[0:3]),
+		Left:      clockDuration(c.Data[3:6]),
+		Running:   CLOCK_SIDE_NONE,
+		Connected: status&0x20 == 0,
+	}
+
+	if status&0x01 != 0 {
+		// the lever is down on the side whose opponent is to move
+		state.Running = CLOCK_SIDE_LEFT
+		if status&0x02 != 0 {
+			state.Running = CLOCK_SIDE_RIGHT
+		}
+	}
+
+	return state
+}
+
+// clockDuration decodes hours, minutes and seconds. Minutes and seconds are BCD encoded.
+func clockDuration(hms []byte) time.Duration {
+	hours := int(hms[0] & 0x0f)
+	minutes := int(hms[1]>>4)*10 + int(hms[1]&0x0f)
+	seconds := int(hms[2]>>4)*10 + int(hms[2]&0x0f)
+
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+}
+
+// clockCommand wraps a clock command into a message for the board
+func clockCommand(cmd ...byte) []byte {
+	msg := []byte{DGT_CLOCK_MESSAGE, byte(len(cmd) + 2), DGT_CMD_CLOCK_START_MESSAGE}
+	msg = append(msg, cmd...)
+	return append(msg, DGT_CMD_CLOCK_END_MESSAGE)
+}
+
+// ClockText shows a text of up to CLOCK_TEXT_SIZE characters on the clock
+func ClockText(text string, beep bool) []byte {
+	cmd := []byte{DGT_CMD_CLOCK_ASCII}
+	for i := 0; i < CLOCK_TEXT_SIZE; i++ {
+		c := byte(' ')
+		if i < len(text) {
+			c = text[i]
+		}
+		cmd = append(cmd, c)
+	}
+
+	var beepTime byte
+	if beep {
+		beepTime = 0x03
+	}
+	return clockCommand(append(cmd, beepTime)...)
+}
+
+// ClockSetAndRun sets the times of the clock and starts the time of the given side
+func ClockSetAndRun(left, right time.Duration, running ClockSide) []byte {
+	cmd := []byte{DGT_CMD_CLOCK_SETNRUN}
+	cmd = append(cmd, hms(left)...)
+	cmd = append(cmd, hms(right)...)
+	return clockCommand(append(cmd, byte(running))...)
+}
+
+// ClockEnd shows the times again after a text was displayed
+func ClockEnd() []byte {
+	return clockCommand(DGT_CMD_CLOCK_END)
+}
+
+func hms(d time.Duration) []byte {
+	if d < 0 {
+		d = 0
+	}
+
+	seconds := int(d.Round(time.Second).Seconds())
+	return []byte{byte(seconds / 3600), byte(seconds / 60 % 60), byte(seconds % 60)}
+}
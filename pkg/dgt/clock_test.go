@@ -0,0 +1,72 @@
+package dgt
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClockCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		cmd      []byte
+		expected []byte
+	}{
+		{name: "set and run", cmd: ClockSetAndRun(5*time.Minute, time.Hour+30*time.Minute+5*time.Second, CLOCK_SIDE_LEFT),
+			expected: []byte{DGT_CLOCK_MESSAGE, 10, DGT_CMD_CLOCK_START_MESSAGE, DGT_CMD_CLOCK_SETNRUN, 0, 5, 0, 1, 30, 5, 0x01, DGT_CMD_CLOCK_END_MESSAGE}},
+		{name: "set and stop with rounded times", cmd: ClockSetAndRun(-time.Second, 59600*time.Millisecond, CLOCK_SIDE_NONE),
+			expected: []byte{DGT_CLOCK_MESSAGE, 10, DGT_CMD_CLOCK_START_MESSAGE, DGT_CMD_CLOCK_SETNRUN, 0, 0, 0, 0, 1, 0, 0x04, DGT_CMD_CLOCK_END_MESSAGE}},
+		{name: "text with beep", cmd: ClockText("draw?", true),
+			expected: []byte{DGT_CLOCK_MESSAGE, 12, DGT_CMD_CLOCK_START_MESSAGE, DGT_CMD_CLOCK_ASCII, 'd', 'r', 'a', 'w', '?', ' ', ' ', ' ', 0x03, DGT_CMD_CLOCK_END_MESSAGE}},
+		{name: "text cut to the display", cmd: ClockText("resign please", false),
+			expected: []byte{DGT_CLOCK_MESSAGE, 12, DGT_CMD_CLOCK_START_MESSAGE, DGT_CMD_CLOCK_ASCII, 'r', 'e', 's', 'i', 'g', 'n', ' ', 'p', 0x00, DGT_CMD_CLOCK_END_MESSAGE}},
+		{name: "end of text", cmd: ClockEnd(),
+			expected: []byte{DGT_CLOCK_MESSAGE, 3, DGT_CMD_CLOCK_START_MESSAGE, DGT_CMD_CLOCK_END, DGT_CMD_CLOCK_END_MESSAGE}},
+	}
+
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.cmd, test.expected) {
+			t.Errorf("%s: expected % x, got % x", test.name, test.expected, test.cmd)
+		}
+	}
+}
+
+func TestClockTime(t *testing.T) {
+	times := ClockState{Left: 5*time.Minute + 9*time.Second, Right: time.Hour + 30*time.Minute, Connected: true}
+
+	tests := []struct {
+		name   string
+		data   [7]byte
+		ack    bool
+		button ClockButton
+		state  ClockState
+	}{
+		{name: "back", data: [7]byte{0x0a, 0, 0x08, 0x2a, 0, 0x31, 0}, ack: true, button: CLOCK_BUTTON_BACK},
+		{name: "minus", data: [7]byte{0x0a, 0, 0x08, 0x2a, 0, 0x32, 0}, ack: true, button: CLOCK_BUTTON_MINUS},
+		{name: "play", data: [7]byte{0x0a, 0, 0x08, 0x2a, 0, 0x33, 0}, ack: true, button: CLOCK_BUTTON_PLAY},
+		{name: "plus", data: [7]byte{0x0a, 0, 0x08, 0x2a, 0, 0x34, 0}, ack: true, button: CLOCK_BUTTON_PLUS},
+		{name: "next", data: [7]byte{0x0a, 0, 0x08, 0x2a, 0, 0x35, 0}, ack: true, button: CLOCK_BUTTON_NEXT},
+		{name: "unknown button", data: [7]byte{0x0a, 0, 0x08, 0x2a, 0, 0x36, 0}, ack: true, button: CLOCK_BUTTON_NONE},
+		{name: "acknowledged command", data: [7]byte{0x0a, 0, 0x0b, 0x2a, 0, 0, 0}, ack: true, button: CLOCK_BUTTON_NONE},
+		{name: "stopped", data: [7]byte{0x01, 0x30, 0x00, 0x00, 0x05, 0x09, 0x00}, button: CLOCK_BUTTON_NONE,
+			state: ClockState{Left: times.Left, Right: times.Right, Running: CLOCK_SIDE_NONE, Connected: true}},
+		{name: "left running", data: [7]byte{0x01, 0x30, 0x00, 0x00, 0x05, 0x09, 0x01}, button: CLOCK_BUTTON_NONE,
+			state: ClockState{Left: times.Left, Right: times.Right, Running: CLOCK_SIDE_LEFT, Connected: true}},
+		{name: "right running", data: [7]byte{0x01, 0x30, 0x00, 0x00, 0x05, 0x09, 0x03}, button: CLOCK_BUTTON_NONE,
+			state: ClockState{Left: times.Left, Right: times.Right, Running: CLOCK_SIDE_RIGHT, Connected: true}},
+		{name: "no clock", data: [7]byte{6: 0x20}, button: CLOCK_BUTTON_NONE, state: ClockState{Running: CLOCK_SIDE_NONE}},
+	}
+
+	for _, test := range tests {
+		clock := ClockTime{Data: test.data}
+		if clock.IsAck() != test.ack {
+			t.Errorf("%s: expected acknowledgement %v", test.name, test.ack)
+		}
+		if button := clock.Button(); button != test.button {
+			t.Errorf("%s: expected the button %d, got %d", test.name, test.button, button)
+		}
+		if !test.ack && clock.State() != test.state {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.state, clock.State())
+		}
+	}
+}
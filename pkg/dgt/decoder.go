@@ -0,0 +1,93 @@
+package dgt
+
+import (
+	"io"
+	"log"
+)
+
+const READ_SIZE = 1024
+
+// Decoder splits the byte stream of a board into messages. Messages may be split over several reads or several
+// messages may arrive with one read.
+type Decoder struct {
+	r       io.Reader
+	buf     []byte
+	chunk   []byte
+	skipped int
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r:     r,
+		buf:   []byte{},
+		chunk: make([]byte, READ_SIZE),
+	}
+}
+
+// Next blocks until a complete message was read. Bytes not belonging to a message of a known type are skipped.
+func (d *Decoder) Next() (Message, error) {
+	for {
+		if msg := d.frame(); msg != nil {
+			return msg, nil
+		}
+
+		n, err := d.r.Read(d.chunk)
+		d.buf = append(d.buf, d.chunk[:n]...)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Buffered returns the number of bytes read but not yet decoded
+func (d *Decoder) Buffered() int {
+	return len(d.buf)
+}
+
+// frame removes the first complete message from the buffer or returns nil if the buffer does not contain one
+func (d *Decoder) frame() Message {
+	for len(d.buf) > 0 {
+		t := MessageType(d.buf[0])
+		if _, known := messageNames[t]; !known {
+			d.skip()
+			continue
+		}
+		if len(d.buf) < HEADER_SIZE {
+			return nil
+		}
+
+		// the length is split into two bytes of 7 bits
+		if d.buf[1]&MESSAGE_BIT != 0 || d.buf[2]&MESSAGE_BIT != 0 {
+			d.skip()
+			continue
+		}
+		size := int(d.buf[1])<<7 | int(d.buf[2])
+
+		expected, fixed := messageSizes[t]
+		if size < HEADER_SIZE || (fixed && size != expected) {
+			d.skip()
+			continue
+		}
+		if len(d.buf) < size {
+			return nil
+		}
+
+		payload := make([]byte, size-HEADER_SIZE)
+		copy(payload, d.buf[HEADER_SIZE:size])
+		d.buf = d.buf[size:]
+
+		if d.skipped > 0 {
+			log.Printf("skipped %d bytes not belonging to a DGT message", d.skipped)
+			d.skipped = 0
+		}
+
+		return parse(t, payload)
+	}
+
+	return nil
+}
+
+func (d *Decoder) skip() {
+	d.buf = d.buf[1:]
+	d.skipped++
+}
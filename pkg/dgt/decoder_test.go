@@ -0,0 +1,159 @@
+package dgt
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// chunkReader returns one chunk per read
+type chunkReader struct {
+	chunks [][]byte
+}
+
+func (r *chunkReader) Read(b []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, r.chunks[0])
+	r.chunks[0] = r.chunks[0][n:]
+	if len(r.chunks[0]) == 0 {
+		r.chunks = r.chunks[1:]
+	}
+	return n, nil
+}
+
+// decodeAll decodes the chunks until the reader is exhausted
+func decodeAll(t *testing.T, chunks ...[]byte) []Message {
+	t.Helper()
+	d := NewDecoder(&chunkReader{chunks: chunks})
+
+	messages := []Message{}
+	for {
+		msg, err := d.Next()
+		if err == io.EOF {
+			return messages
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, msg)
+	}
+}
+
+func join(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func TestDecoder(t *testing.T) {
+	update := Encode(DGT_MSG_FIELD_UPDATE, []byte{12, 1})
+	dump := Encode(DGT_MSG_BOARD_DUMP, make([]byte, 64))
+	trademark := Encode(DGT_MSG_TRADEMARK, []byte("Digital Game Technology\x00\x00"))
+
+	tests := []struct {
+		name     string
+		chunks   [][]byte
+		expected []Message
+	}{
+		{
+			name:     "one message per read",
+			chunks:   [][]byte{update, dump},
+			expected: []Message{FieldUpdate{Field: 12, Piece: 1}, BoardDump{}},
+		},
+		{
+			name:     "message split across reads",
+			chunks:   [][]byte{update[:1], update[1:3], update[3:4], update[4:]},
+			expected: []Message{FieldUpdate{Field: 12, Piece: 1}},
+		},
+		{
+			name:     "header split from the payload",
+			chunks:   [][]byte{dump[:HEADER_SIZE], dump[HEADER_SIZE:40], dump[40:]},
+			expected: []Message{BoardDump{}},
+		},
+		{
+			name:     "several messages in one read",
+			chunks:   [][]byte{join(update, trademark, dump)},
+			expected: []Message{FieldUpdate{Field: 12, Piece: 1}, Trademark{Text: "Digital Game Technology"}, BoardDump{}},
+		},
+		{
+			name:     "several messages split at random",
+			chunks:   [][]byte{join(update, trademark[:5]), join(trademark[5:], dump[:2]), dump[2:]},
+			expected: []Message{FieldUpdate{Field: 12, Piece: 1}, Trademark{Text: "Digital Game Technology"}, BoardDump{}},
+		},
+		{
+			name:     "garbage before a message",
+			chunks:   [][]byte{{0x00, 0x41, 0x7f, 0xff}, update},
+			expected: []Message{FieldUpdate{Field: 12, Piece: 1}},
+		},
+		{
+			name:     "garbage between messages",
+			chunks:   [][]byte{join(update, []byte{0x13, 0x37}, update)},
+			expected: []Message{FieldUpdate{Field: 12, Piece: 1}, FieldUpdate{Field: 12, Piece: 1}},
+		},
+		{
+			name: "resync after a known type with a wrong length",
+			// the field update claims to be 7 bytes long, the decoder skips its type and finds the next message
+			chunks:   [][]byte{{byte(DGT_MSG_FIELD_UPDATE), 0x00, 0x07}, update},
+			expected: []Message{FieldUpdate{Field: 12, Piece: 1}},
+		},
+		{
+			name:     "resync after a length with the message bit",
+			chunks:   [][]byte{{byte(DGT_MSG_FIELD_UPDATE), 0x80, 0x05}, update},
+			expected: []Message{FieldUpdate{Field: 12, Piece: 1}},
+		},
+		{
+			name:     "incomplete message at the end",
+			chunks:   [][]byte{update, dump[:10]},
+			expected: []Message{FieldUpdate{Field: 12, Piece: 1}},
+		},
+	}
+
+	for _, test := range tests {
+		if messages := decodeAll(t, test.chunks...); !reflect.DeepEqual(messages, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, messages)
+		}
+	}
+}
+
+func TestDecoderLengthHeader(t *testing.T) {
+	// 300 bytes need both 7 bit halves of the length
+	payload := make([]byte, 300)
+	for i := range payload {
+		payload[i] = byte(i % 0x7f)
+	}
+
+	msg := Encode(DGT_MSG_EE_MOVES, payload)
+	if !bytes.Equal(msg[:HEADER_SIZE], []byte{byte(DGT_MSG_EE_MOVES), 0x02, 0x2f}) {
+		t.Fatalf("expected the length 303 as 0x02 0x2f, got % x", msg[:HEADER_SIZE])
+	}
+
+	messages := decodeAll(t, msg[:200], msg[200:])
+	if len(messages) != 1 {
+		t.Fatalf("expected one message, got %v", messages)
+	}
+	if moves, ok := messages[0].(EEMoves); !ok || !bytes.Equal(moves.Data, payload) {
+		t.Errorf("expected the payload of 300 bytes, got %v", messages[0])
+	}
+
+	// the largest message fits into 14 bits
+	large := Encode(DGT_MSG_EE_MOVES, make([]byte, MAX_MESSAGE_SIZE-HEADER_SIZE))
+	if !bytes.Equal(large[1:HEADER_SIZE], []byte{0x7f, 0x7f}) {
+		t.Errorf("expected the length 0x7f 0x7f, got % x", large[1:HEADER_SIZE])
+	}
+	if messages := decodeAll(t, large); len(messages) != 1 || len(messages[0].(EEMoves).Data) != MAX_MESSAGE_SIZE-HEADER_SIZE {
+		t.Errorf("expected the largest message to be decoded")
+	}
+}
+
+func TestDecoderBuffered(t *testing.T) {
+	update := Encode(DGT_MSG_FIELD_UPDATE, []byte{12, 1})
+	d := NewDecoder(&chunkReader{chunks: [][]byte{join(update, update[:2])}})
+
+	if _, err := d.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if d.Buffered() != 2 {
+		t.Errorf("expected 2 buffered bytes, got %d", d.Buffered())
+	}
+}
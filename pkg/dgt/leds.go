@@ -0,0 +1,29 @@
+package dgt
+
+// Modes of the LED command of a DGT Pegasus
+const (
+	DGT_LEDS_OFF = 0x00
+	DGT_LEDS_ON  = 0x05
+)
+
+// LED_SPEED is the blinking speed of the LEDs of a DGT Pegasus
+const LED_SPEED = 0x12
+
+// LED_INTENSITY is the brightness of the LEDs of a DGT Pegasus
+const LED_INTENSITY = 0x01
+
+// PegasusLEDs lights the LEDs of the given fields of a DGT Pegasus
+func PegasusLEDs(fields ...byte) []byte {
+	if len(fields) == 0 {
+		return PegasusLEDsOff()
+	}
+
+	msg := []byte{DGT_SET_LEDS, byte(len(fields) + 5), DGT_LEDS_ON, LED_SPEED, 0x00, LED_INTENSITY}
+	msg = append(msg, fields...)
+	return append(msg, 0x00)
+}
+
+// PegasusLEDsOff turns off all LEDs of a DGT Pegasus
+func PegasusLEDsOff() []byte {
+	return []byte{DGT_SET_LEDS, 0x02, DGT_LEDS_OFF, 0x00}
+}
@@ -0,0 +1,139 @@
+package dgt
+
+import (
+	"strings"
+)
+
+// Message is a message sent by the board
+type Message interface {
+	Type() MessageType
+}
+
+// BoardDump contains the pieces of all squares, starting with a8 and ending with h1 for a board in normal orientation
+type BoardDump struct {
+	Squares [64]byte
+}
+
+// FieldUpdate is sent when a piece was placed on or lifted from a square
+type FieldUpdate struct {
+	Field byte
+	Piece byte
+}
+
+// ClockTime contains the times and the state of a clock connected to the board
+type ClockTime struct {
+	Data [7]byte
+}
+
+// EEMoves contains the moves stored in the EEPROM of the board
+type EEMoves struct {
+	Data []byte
+}
+
+type BusAddress struct {
+	Address int
+}
+
+type SerialNumber struct {
+	Serial string
+}
+
+type LongSerialNumber struct {
+	Serial string
+}
+
+type Trademark struct {
+	Text string
+}
+
+type Version struct {
+	Major int
+	Minor int
+}
+
+// BoardDump50 contains the last 50 field updates
+type BoardDump50 struct {
+	Black bool
+	Data  []byte
+}
+
+// BatteryStatus is sent by boards with a battery, e.g. bluetooth boards
+type BatteryStatus struct {
+	Data []byte
+}
+
+// Percent returns the remaining battery capacity or -1 if it is unknown
+func (b BatteryStatus) Percent() int {
+	if len(b.Data) == 0 || b.Data[0] > 100 {
+		return -1
+	}
+	return int(b.Data[0])
+}
+
+type None struct{}
+
+func (BoardDump) Type() MessageType        { return DGT_MSG_BOARD_DUMP }
+func (FieldUpdate) Type() MessageType      { return DGT_MSG_FIELD_UPDATE }
+func (ClockTime) Type() MessageType        { return DGT_MSG_BWTIME }
+func (EEMoves) Type() MessageType          { return DGT_MSG_EE_MOVES }
+func (BusAddress) Type() MessageType       { return DGT_MSG_BUSADRES }
+func (SerialNumber) Type() MessageType     { return DGT_MSG_SERIALNR }
+func (LongSerialNumber) Type() MessageType { return DGT_MSG_LONG_SERIALNR }
+func (Trademark) Type() MessageType        { return DGT_MSG_TRADEMARK }
+func (Version) Type() MessageType          { return DGT_MSG_VERSION }
+func (BatteryStatus) Type() MessageType    { return DGT_MSG_BATTERY_STATUS }
+func (None) Type() MessageType             { return DGT_MSG_NONE }
+
+func (d BoardDump50) Type() MessageType {
+	if d.Black {
+		return DGT_MSG_BOARD_DUMP_50B
+	}
+	return DGT_MSG_BOARD_DUMP_50W
+}
+
+// parse creates the message of a known type from its payload. The size of fixed size messages is checked by the decoder.
+func parse(t MessageType, payload []byte) Message {
+	switch t {
+	case DGT_MSG_NONE:
+		return None{}
+	case DGT_MSG_BOARD_DUMP:
+		dump := BoardDump{}
+		copy(dump.Squares[:], payload)
+		return dump
+	case DGT_MSG_FIELD_UPDATE:
+		return FieldUpdate{Field: payload[0], Piece: payload[1]}
+	case DGT_MSG_BWTIME:
+		clock := ClockTime{}
+		copy(clock.Data[:], payload)
+		return clock
+	case DGT_MSG_EE_MOVES:
+		return EEMoves{Data: payload}
+	case DGT_MSG_BUSADRES:
+		return BusAddress{Address: int(payload[0]&0x7f)<<7 | int(payload[1]&0x7f)}
+	case DGT_MSG_SERIALNR:
+		return SerialNumber{Serial: text(payload)}
+	case DGT_MSG_LONG_SERIALNR:
+		return LongSerialNumber{Serial: text(payload)}
+	case DGT_MSG_TRADEMARK:
+		return Trademark{Text: text(payload)}
+	case DGT_MSG_VERSION:
+		return Version{Major: int(payload[0]), Minor: int(payload[1])}
+	case DGT_MSG_BOARD_DUMP_50B, DGT_MSG_BOARD_DUMP_50W:
+		return BoardDump50{Black: t == DGT_MSG_BOARD_DUMP_50B, Data: payload}
+	case DGT_MSG_BATTERY_STATUS:
+		return BatteryStatus{Data: payload}
+	}
+
+	return None{}
+}
+
+func text(payload []byte) string {
+	return strings.TrimSpace(strings.TrimRight(string(payload), "\x00"))
+}
+
+// Encode creates the bytes of a message as sent by the board
+func Encode(t MessageType, payload []byte) []byte {
+	size := HEADER_SIZE + len(payload)
+	msg := []byte{byte(t), byte(size>>7) & 0x7f, byte(size) & 0x7f}
+	return append(msg, payload...)
+}
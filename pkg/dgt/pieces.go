@@ -0,0 +1,50 @@
+package dgt
+
+import (
+	"github.com/notnil/chess"
+)
+
+var pieces = map[byte]chess.Piece{
+	DGT_WPAWN:   chess.WhitePawn,
+	DGT_WROOK:   chess.WhiteRook,
+	DGT_WKNIGHT: chess.WhiteKnight,
+	DGT_WBISHOP: chess.WhiteBishop,
+	DGT_WKING:   chess.WhiteKing,
+	DGT_WQUEEN:  chess.WhiteQueen,
+	DGT_BPAWN:   chess.BlackPawn,
+	DGT_BROOK:   chess.BlackRook,
+	DGT_BKNIGHT: chess.BlackKnight,
+	DGT_BBISHOP: chess.BlackBishop,
+	DGT_BKING:   chess.BlackKing,
+	DGT_BQUEEN:  chess.BlackQueen,
+}
+
+// Piece returns the piece of a piece code sent by the board. Empty squares and special pieces are chess.NoPiece.
+func Piece(code byte) chess.Piece {
+	if piece, ok := pieces[code]; ok {
+		return piece
+	}
+
+	return chess.NoPiece
+}
+
+// PieceCode returns the code of a piece sent by the board
+func PieceCode(piece chess.Piece) byte {
+	for code, p := range pieces {
+		if p == piece {
+			return code
+		}
+	}
+
+	return DGT_EMPTY
+}
+
+// Field returns the field index the board uses for a square if the board is in normal orientation
+func Field(sq chess.Square) byte {
+	return byte((7-int(sq.Rank()))*8 + int(sq.File()))
+}
+
+// Square returns the square of a field index of the board in normal orientation
+func Square(field byte) chess.Square {
+	return chess.NewSquare(chess.File(field%8), chess.Rank(7-field/8))
+}
@@ -0,0 +1,111 @@
+package dgt
+
+// Commands sent to the board
+const (
+	DGT_SEND_RESET           = 0x40
+	DGT_SEND_CLK             = 0x41
+	DGT_SEND_BRD             = 0x42
+	DGT_SEND_UPDATE          = 0x43
+	DGT_SEND_UPDATE_BRD      = 0x44
+	DGT_RETURN_SERIALNR      = 0x45
+	DGT_RETURN_BUSADRES      = 0x46
+	DGT_SEND_TRADEMARK       = 0x47
+	DGT_SEND_EE_MOVES        = 0x49
+	DGT_SEND_UPDATE_NICE     = 0x4b
+	DGT_SEND_BATTERY_STATUS  = 0x4c
+	DGT_SEND_VERSION         = 0x4d
+	DGT_SEND_BRD_50B         = 0x50
+	DGT_SCAN_50B             = 0x51
+	DGT_SEND_BRD_50W         = 0x52
+	DGT_SCAN_50W             = 0x53
+	DGT_SCAN_100             = 0x54
+	DGT_RETURN_LONG_SERIALNR = 0x55
+	DGT_SET_LEDS             = 0x60
+	DGT_CLOCK_MESSAGE        = 0x2b
+)
+
+const MESSAGE_BIT = 0x80
+
+// HEADER_SIZE is the size of the message type and the 14 bit message length preceding every message
+const HEADER_SIZE = 3
+
+// MAX_MESSAGE_SIZE is the largest length that fits into the 14 bit length of the header
+const MAX_MESSAGE_SIZE = 0x3fff
+
+type MessageType byte
+
+// Messages sent by the board
+const (
+	DGT_MSG_NONE           MessageType = MESSAGE_BIT | 0x00
+	DGT_MSG_BOARD_DUMP     MessageType = MESSAGE_BIT | 0x06
+	DGT_MSG_BWTIME         MessageType = MESSAGE_BIT | 0x0d
+	DGT_MSG_FIELD_UPDATE   MessageType = MESSAGE_BIT | 0x0e
+	DGT_MSG_EE_MOVES       MessageType = MESSAGE_BIT | 0x0f
+	DGT_MSG_BUSADRES       MessageType = MESSAGE_BIT | 0x10
+	DGT_MSG_SERIALNR       MessageType = MESSAGE_BIT | 0x11
+	DGT_MSG_TRADEMARK      MessageType = MESSAGE_BIT | 0x12
+	DGT_MSG_VERSION        MessageType = MESSAGE_BIT | 0x13
+	DGT_MSG_BOARD_DUMP_50B MessageType = MESSAGE_BIT | 0x14
+	DGT_MSG_BOARD_DUMP_50W MessageType = MESSAGE_BIT | 0x15
+	DGT_MSG_BATTERY_STATUS MessageType = MESSAGE_BIT | 0x20
+	DGT_MSG_LONG_SERIALNR  MessageType = MESSAGE_BIT | 0x22
+)
+
+// messageSizes are the sizes including the header of all messages with a fixed size
+var messageSizes = map[MessageType]int{
+	DGT_MSG_NONE:           HEADER_SIZE,
+	DGT_MSG_BOARD_DUMP:     HEADER_SIZE + 64,
+	DGT_MSG_BWTIME:         HEADER_SIZE + 7,
+	DGT_MSG_FIELD_UPDATE:   HEADER_SIZE + 2,
+	DGT_MSG_BUSADRES:       HEADER_SIZE + 2,
+	DGT_MSG_SERIALNR:       HEADER_SIZE + 5,
+	DGT_MSG_VERSION:        HEADER_SIZE + 2,
+	DGT_MSG_BOARD_DUMP_50B: HEADER_SIZE + 50,
+	DGT_MSG_BOARD_DUMP_50W: HEADER_SIZE + 50,
+	DGT_MSG_LONG_SERIALNR:  HEADER_SIZE + 10,
+}
+
+// messageNames are used for logging
+var messageNames = map[MessageType]string{
+	DGT_MSG_NONE:           "none",
+	DGT_MSG_BOARD_DUMP:     "board dump",
+	DGT_MSG_BWTIME:         "clock time",
+	DGT_MSG_FIELD_UPDATE:   "field update",
+	DGT_MSG_EE_MOVES:       "EE moves",
+	DGT_MSG_BUSADRES:       "bus address",
+	DGT_MSG_SERIALNR:       "serial number",
+	DGT_MSG_TRADEMARK:      "trademark",
+	DGT_MSG_VERSION:        "version",
+	DGT_MSG_BOARD_DUMP_50B: "board dump 50 black",
+	DGT_MSG_BOARD_DUMP_50W: "board dump 50 white",
+	DGT_MSG_BATTERY_STATUS: "battery status",
+	DGT_MSG_LONG_SERIALNR:  "long serial number",
+}
+
+func (t MessageType) String() string {
+	if name, ok := messageNames[t]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Pieces of board dumps and field updates
+const (
+	DGT_EMPTY   = 0x00
+	DGT_WPAWN   = 0x01
+	DGT_WROOK   = 0x02
+	DGT_WKNIGHT = 0x03
+	DGT_WBISHOP = 0x04
+	DGT_WKING   = 0x05
+	DGT_WQUEEN  = 0x06
+	DGT_BPAWN   = 0x07
+	DGT_BROOK   = 0x08
+	DGT_BKNIGHT = 0x09
+	DGT_BBISHOP = 0x0a
+	DGT_BKING   = 0x0b
+	DGT_BQUEEN  = 0x0c
+	// DGT_PIECE1 to DGT_PIECE3 are special pieces used to signal draw, white and black wins
+	DGT_PIECE1 = 0x0d
+	DGT_PIECE2 = 0x0e
+	DGT_PIECE3 = 0x0f
+)
@@ -0,0 +1,319 @@
+package dgt
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// SIMULATOR_PORT is the port name of the simulator in the config
+const SIMULATOR_PORT = "simulator"
+
+// Simulator is a virtual DGT board speaking the serial protocol of the board. Pieces can be lifted and dropped or
+// whole games from a PGN can be played on it. Simulators of other boards show its pieces.
+type Simulator struct {
+	mutex   *sync.Mutex
+	fields  [64]byte
+	updates bool
+	conn    net.Conn
+	out     chan []byte
+	serial  string
+	leds    []byte
+}
+
+// NewSimulator creates a simulated board showing the start position
+func NewSimulator() *Simulator {
+	s := &Simulator{
+		mutex:  &sync.Mutex{},
+		serial: "00000",
+	}
+	s.setBoard(*chess.NewGame().Position().Board())
+
+	return s
+}
+
+// Connect returns the connection to the board a driver reads from and writes to, like a serial port.
+// An existing connection is closed.
+func (s *Simulator) Connect() io.ReadWriteCloser {
+	board, driver := net.Pipe()
+
+	s.mutex.Lock()
+	if s.conn != nil {
+		s.conn.Close()
+		close(s.out)
+	}
+	s.conn = board
+	s.updates = false
+	// the driver may write commands before it reads, so the answers are written in the background
+	s.out = make(chan []byte, 256)
+	out := s.out
+	s.mutex.Unlock()
+
+	go s.writeLoop(board, out)
+	go s.readLoop(board)
+
+	return driver
+}
+
+// Disconnect simulates unplugging the board
+func (s *Simulator) Disconnect() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+		close(s.out)
+	}
+}
+
+func (s *Simulator) writeLoop(conn net.Conn, out chan []byte) {
+	for msg := range out {
+		if _, err := conn.Write(msg); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Simulator) readLoop(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	for {
+		cmd, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+
+		switch cmd {
+		case DGT_CLOCK_MESSAGE, DGT_SET_LEDS:
+			size, err := r.ReadByte()
+			if err != nil {
+				return
+			}
+			payload := make([]byte, size)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			// the simulator has no clock, but the LEDs of a Pegasus
+			if cmd == DGT_SET_LEDS {
+				s.setLEDs(payload)
+			}
+		default:
+			s.command(cmd)
+		}
+	}
+}
+
+func (s *Simulator) command(cmd byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch cmd {
+	case DGT_SEND_RESET:
+		s.updates = false
+	case DGT_SEND_BRD:
+		s.send(Encode(DGT_MSG_BOARD_DUMP, s.fields[:]))
+	case DGT_SEND_UPDATE, DGT_SEND_UPDATE_BRD, DGT_SEND_UPDATE_NICE:
+		s.updates = true
+	case DGT_RETURN_SERIALNR:
+		s.send(Encode(DGT_MSG_SERIALNR, []byte(s.serial)))
+	case DGT_RETURN_LONG_SERIALNR:
+		s.send(Encode(DGT_MSG_LONG_SERIALNR, []byte("SIM"+s.serial+"00")))
+	case DGT_SEND_TRADEMARK:
+		s.send(Encode(DGT_MSG_TRADEMARK, []byte("Virtual DGT board")))
+	case DGT_SEND_VERSION:
+		s.send(Encode(DGT_MSG_VERSION, []byte{1, 0}))
+	case DGT_RETURN_BUSADRES:
+		s.send(Encode(DGT_MSG_BUSADRES, []byte{0, 0}))
+	case DGT_SEND_BATTERY_STATUS:
+		// like boards connected by USB the simulator has no battery
+	default:
+		log.Printf("simulator ignores command 0x%02x", cmd)
+	}
+}
+
+// setLEDs lights the fields of a Pegasus LED command, see PegasusLEDs
+func (s *Simulator) setLEDs(payload []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.leds = nil
+	if len(payload) > 5 && payload[0] == DGT_LEDS_ON {
+		s.leds = append(s.leds, payload[4:len(payload)-1]...)
+	}
+	sort.Slice(s.leds, func(i, j int) bool {
+		return s.leds[i] < s.leds[j]
+	})
+}
+
+// LEDs returns the fields whose LEDs are lit in ascending order
+func (s *Simulator) LEDs() []byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]byte{}, s.leds...)
+}
+
+// send queues a message for the driver. The mutex must be held by the caller.
+func (s *Simulator) send(msg []byte) {
+	if s.conn == nil {
+		return
+	}
+
+	select {
+	case s.out <- msg:
+	default:
+		log.Println("simulator dropped a message")
+	}
+}
+
+// Lift lifts the piece from a square
+func (s *Simulator) Lift(sq chess.Square) {
+	s.Drop(sq, chess.NoPiece)
+}
+
+// Drop places a piece on a square. Dropping chess.NoPiece empties the square.
+func (s *Simulator) Drop(sq chess.Square, piece chess.Piece) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.setField(Field(sq), PieceCode(piece))
+}
+
+// setField changes a field and sends a field update in update mode. The mutex must be held by the caller.
+func (s *Simulator) setField(field, code byte) {
+	if s.fields[field] == code {
+		return
+	}
+
+	s.fields[field] = code
+	if s.updates {
+		s.send(Encode(DGT_MSG_FIELD_UPDATE, []byte{field, code}))
+	}
+}
+
+// SetBoard places the pieces of a board, e.g. to set up a position
+func (s *Simulator) SetBoard(board chess.Board) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.setBoard(board)
+}
+
+func (s *Simulator) setBoard(board chess.Board) {
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		s.setField(Field(sq), PieceCode(board.Piece(sq)))
+	}
+}
+
+// Board returns the pieces on the board
+func (s *Simulator) Board() chess.Board {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	squares := map[chess.Square]chess.Piece{}
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		if piece := Piece(s.fields[Field(sq)]); piece != chess.NoPiece {
+			squares[sq] = piece
+		}
+	}
+	return *chess.NewBoard(squares)
+}
+
+// Move makes a move of the given position on the board the way a player would: captured pieces are lifted first,
+// then the piece is moved. Castling moves the rook as well and promotions place the new piece.
+func (s *Simulator) Move(pos *chess.Position, move *chess.Move) {
+	board := pos.Board()
+	piece := board.Piece(move.S1())
+
+	if move.HasTag(chess.KingSideCastle) || move.HasTag(chess.QueenSideCastle) {
+		s.castle(pos, move)
+		return
+	}
+
+	switch {
+	case move.HasTag(chess.EnPassant):
+		s.Lift(chess.NewSquare(move.S2().File(), move.S1().Rank()))
+	case move.HasTag(chess.Capture):
+		s.Lift(move.S2())
+	}
+
+	s.Lift(move.S1())
+	if move.Promo() != chess.NoPieceType {
+		piece = promoted(move.Promo(), piece.Color())
+	}
+	s.Drop(move.S2(), piece)
+}
+
+// castle lifts the king and the rook and places them on their castled squares. In Chess960 the king may land on the
+// square of the rook, so both are lifted first.
+func (s *Simulator) castle(pos *chess.Position, move *chess.Move) {
+	board := pos.Board()
+	after := pos.Update(move).Board()
+
+	for _, t := range []chess.PieceType{chess.King, chess.Rook} {
+		for sq := chess.A1; sq <= chess.H8; sq++ {
+			if board.Piece(sq).Type() == t && after.Piece(sq) != board.Piece(sq) {
+				s.Lift(sq)
+			}
+		}
+	}
+	for _, t := range []chess.PieceType{chess.King, chess.Rook} {
+		for sq := chess.A1; sq <= chess.H8; sq++ {
+			if after.Piece(sq).Type() == t && after.Piece(sq) != board.Piece(sq) {
+				s.Drop(sq, after.Piece(sq))
+			}
+		}
+	}
+}
+
+// PlayMove makes a move in UCI notation, e.g. "e2e4", of the given game on the board
+func (s *Simulator) PlayMove(game *chess.Game, uci string) (*chess.Move, error) {
+	move, err := chess.UCINotation{}.Decode(game.Position(), uci)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, valid := range game.ValidMoves() {
+		if valid.String() == move.String() {
+			s.Move(game.Position(), valid)
+			return valid, nil
+		}
+	}
+
+	return nil, errors.New("illegal move " + uci)
+}
+
+// PlayPGN sets up the start position of a game and plays all its moves with the given delay between the moves
+func (s *Simulator) PlayPGN(r io.Reader, delay time.Duration) error {
+	pgn, err := chess.PGN(r)
+	if err != nil {
+		return err
+	}
+	game := chess.NewGame(pgn)
+
+	positions := game.Positions()
+	s.SetBoard(*positions[0].Board())
+	for i, move := range game.Moves() {
+		time.Sleep(delay)
+		s.Move(positions[i], move)
+	}
+
+	return nil
+}
+
+func promoted(t chess.PieceType, c chess.Color) chess.Piece {
+	for _, piece := range pieces {
+		if piece.Type() == t && piece.Color() == c {
+			return piece
+		}
+	}
+
+	return chess.NoPiece
+}
@@ -0,0 +1,164 @@
+package game
+
+import (
+	"log"
+
+	"github.com/notnil/chess"
+)
+
+type BoardTakebackMode string
+
+const (
+	BOARD_TAKEBACK_OFF BoardTakebackMode = "off"
+	// BOARD_TAKEBACK_OFFER requests a takeback the opponent has to accept
+	BOARD_TAKEBACK_OFFER BoardTakebackMode = "offer"
+	// BOARD_TAKEBACK_AUTO takes back moves without asking the opponent
+	BOARD_TAKEBACK_AUTO BoardTakebackMode = "auto"
+)
+
+// BoardSync describes the squares of the physical board that differ from the current position
+type BoardSync struct {
+	Diff []SquareDiff
+}
+
+func (s BoardSync) InSync() bool {
+	return len(s.Diff) == 0
+}
+
+// SyncSubscriber is implemented by subscribers showing whether the physical board is in sync with the game
+type SyncSubscriber interface {
+	RenderSync(game chess.Game, sync BoardSync)
+}
+
+// EnableBoard lets the game follow a physical board showing the given board. Bots only move once the board shows
+// the current position, and reverting pieces to an earlier position takes back moves according to the given mode.
+func (g *Game) EnableBoard(takebacks BoardTakebackMode, board chess.Board) {
+	g.boardEnabled = true
+	g.boardTakebacks = takebacks
+	if len(board.SquareMap()) > 0 {
+		g.board = &board
+	}
+}
+
+// UpdateBoard passes a position read from the physical board to the game
+func (g *Game) UpdateBoard(board chess.Board) {
+	if !g.boardEnabled {
+		return
+	}
+
+	g.post(event{eventType: EVENT_BOARD, board: &board})
+}
+
+// boardMatches returns true if the last known board shows the given board. It is true as long as the board is unknown.
+func (g *Game) boardMatches(board *chess.Board) bool {
+	if g.board == nil {
+		return true
+	}
+
+	return len(BoardDiff(*board, *g.board)) == 0
+}
+
+// awaitingBoard returns true if a bot has to wait until the board shows the current position
+func (g *Game) awaitingBoard() bool {
+	if !g.boardEnabled || !g.player(g.game.Position().Turn()).IsBot() {
+		return false
+	}
+
+	return !g.boardMatches(g.game.Position().Board())
+}
+
+// boardShowsMove returns true if the board shows the position after a legal move
+func (g *Game) boardShowsMove() bool {
+	position := g.game.Position()
+	for _, move := range position.ValidMoves() {
+		if g.boardMatches(position.Update(move).Board()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkSync reports the squares of the board that differ from the current position whenever they change
+func (g *Game) checkSync() {
+	if !g.boardEnabled || g.board == nil {
+		return
+	}
+
+	sync := BoardSync{
+		Diff: BoardDiff(*g.game.Position().Board(), *g.board),
+	}
+	if !sync.InSync() && g.boardShowsMove() {
+		// the move is about to be detected by the player
+		return
+	}
+	if g.sync != nil && sameDiff(g.sync.Diff, sync.Diff) {
+		return
+	}
+	g.sync = &sync
+
+	if sync.InSync() {
+		log.Println("Board is in sync")
+	} else {
+		log.Printf("Board differs on %d squares", len(sync.Diff))
+	}
+
+	for _, subscriber := range g.subscribers {
+		if s, ok := subscriber.(SyncSubscriber); ok {
+			s.RenderSync(*g.game, sync)
+		}
+	}
+}
+
+func (g *Game) boardChanged(board chess.Board) {
+	g.board = &board
+
+	position := g.game.Position()
+	if g.boardMatches(position.Board()) {
+		g.boardTakebackPly = -1
+		return
+	}
+
+	if g.boardTakebacks == BOARD_TAKEBACK_OFF || g.boardTakebacks == "" || g.takeback != nil {
+		return
+	}
+
+	if g.boardShowsMove() {
+		// legal moves are detected by the player
+		return
+	}
+
+	positions := g.game.Positions()
+	for i := len(positions) - 2; i >= 0; i-- {
+		if !g.boardMatches(positions[i].Board()) {
+			continue
+		}
+
+		if i == g.boardTakebackPly {
+			// the takeback to this position was already handled
+			return
+		}
+		g.boardTakebackPly = i
+
+		request := &TakebackRequest{
+			By:    g.requester(),
+			Moves: len(positions) - 1 - i,
+		}
+		log.Printf("Board shows the position before %d moves", request.Moves)
+
+		if g.boardTakebacks == BOARD_TAKEBACK_AUTO {
+			if g.takebacksLeft() == 0 {
+				log.Println("no takebacks left")
+				return
+			}
+			g.takeBack(request)
+			return
+		}
+
+		if err := g.requestTakeback(request.By, request.Moves); err != nil {
+			log.Println(err)
+			g.notify(UIAction{Event: EVENT_TAKEBACK_DECLINE})
+		}
+		return
+	}
+}
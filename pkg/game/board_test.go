@@ -0,0 +1,88 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+// boardAfter returns the board after the moves
+func boardAfter(t *testing.T, moves ...string) chess.Board {
+	t.Helper()
+	g := chess.NewGame()
+	play(t, g, moves...)
+	return *g.Position().Board()
+}
+
+func TestBoardTakeback(t *testing.T) {
+	moves := []string{"e4", "e5", "Nf3", "Nc6"}
+	zero := 0
+
+	tests := []struct {
+		name      string
+		mode      BoardTakebackMode
+		limit     *int
+		board     []string
+		moves     int
+		requested int
+	}{
+		{name: "current position", mode: BOARD_TAKEBACK_AUTO, board: moves, moves: 4},
+		{name: "legal move", mode: BOARD_TAKEBACK_AUTO, board: append(moves[:4:4], "Bb5"), moves: 4},
+		{name: "unknown position", mode: BOARD_TAKEBACK_AUTO, board: []string{"d4"}, moves: 4},
+		{name: "off", mode: BOARD_TAKEBACK_OFF, board: moves[:3], moves: 4},
+		{name: "offer one move", mode: BOARD_TAKEBACK_OFFER, board: moves[:3], moves: 4, requested: 1},
+		{name: "offer two moves", mode: BOARD_TAKEBACK_OFFER, board: moves[:2], moves: 4, requested: 2},
+		{name: "auto one move", mode: BOARD_TAKEBACK_AUTO, board: moves[:3], moves: 3},
+		{name: "auto two moves", mode: BOARD_TAKEBACK_AUTO, board: moves[:2], moves: 2},
+		{name: "auto to the start", mode: BOARD_TAKEBACK_AUTO, board: nil, moves: 0},
+		{name: "auto without takebacks left", mode: BOARD_TAKEBACK_AUTO, limit: &zero, board: moves[:3], moves: 4},
+	}
+
+	for _, test := range tests {
+		g := newTakebackGame(t, moves)
+		g.EnableBoard(test.mode, boardAfter(t, moves...))
+		if test.limit != nil {
+			g.SetTakebackLimit(*test.limit)
+		}
+
+		g.boardChanged(boardAfter(t, test.board...))
+
+		if len(g.game.Moves()) != test.moves {
+			t.Errorf("%s: expected %d moves, got %d", test.name, test.moves, len(g.game.Moves()))
+		}
+		requested := 0
+		if g.takeback != nil {
+			requested = g.takeback.Moves
+			if g.takeback.By != chess.Black {
+				t.Errorf("%s: expected black to request the takeback, got %s", test.name, g.takeback.By.Name())
+			}
+		}
+		if requested != test.requested {
+			t.Errorf("%s: expected a takeback of %d moves to be requested, got %d", test.name, test.requested, requested)
+		}
+	}
+}
+
+func TestBoardTakebackRequestedOnce(t *testing.T) {
+	moves := []string{"e4", "e5", "Nf3", "Nc6"}
+	g := newTakebackGame(t, moves)
+	g.EnableBoard(BOARD_TAKEBACK_OFFER, boardAfter(t, moves...))
+
+	g.boardChanged(boardAfter(t, moves[:3]...))
+	if err := g.answerTakeback(false); err != nil {
+		t.Fatal(err)
+	}
+
+	// the board still shows the declined position
+	g.boardChanged(boardAfter(t, moves[:3]...))
+	if g.takeback != nil {
+		t.Error("expected the declined takeback not to be requested again")
+	}
+
+	// the takeback can be requested again once the board was put back
+	g.boardChanged(boardAfter(t, moves...))
+	g.boardChanged(boardAfter(t, moves[:3]...))
+	if g.takeback == nil {
+		t.Error("expected the takeback to be requested again")
+	}
+}
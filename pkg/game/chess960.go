@@ -0,0 +1,81 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const VARIANT_CHESS960 = "Chess960"
+
+const CHESS960_STANDARD_POSITION = 518
+
+var chess960Knights = [10][2]int{
+	{0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 2},
+	{1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4},
+}
+
+// RandomChess960Position returns a random start position number between 0 and 959
+func RandomChess960Position() int {
+	return rand.New(rand.NewSource(time.Now().UnixNano())).Intn(960)
+}
+
+// Chess960BackRank returns the white back rank of a start position using Scharnagl's numbering, e.g. "RNBQKBNR" for 518
+func Chess960BackRank(n int) (string, error) {
+	if n < 0 || n > 959 {
+		return "", fmt.Errorf("invalid Chess960 start position %d", n)
+	}
+
+	rank := make([]rune, 8)
+
+	rank[(n%4)*2+1] = 'B'
+	n = n / 4
+	rank[(n%4)*2] = 'B'
+	n = n / 4
+
+	placeOnEmpty := func(index int, piece rune) {
+		for i := range rank {
+			if rank[i] != 0 {
+				continue
+			}
+			if index == 0 {
+				rank[i] = piece
+				return
+			}
+			index--
+		}
+	}
+
+	placeOnEmpty(n%6, 'Q')
+	n = n / 6
+
+	knights := chess960Knights[n]
+	// the second knight is placed first so the index of the first one is not shifted
+	placeOnEmpty(knights[1], 'N')
+	placeOnEmpty(knights[0], 'N')
+
+	placeOnEmpty(0, 'R')
+	placeOnEmpty(0, 'K')
+	placeOnEmpty(0, 'R')
+
+	return string(rank), nil
+}
+
+// Chess960FEN returns the FEN of a Chess960 start position. The castling rights are given in Shredder-FEN, i.e. the
+// files of the rooks, so castling moves are encoded as the king moving to the rook like Chess960 engines expect.
+func Chess960FEN(n int) (string, error) {
+	rank, err := Chess960BackRank(n)
+	if err != nil {
+		return "", err
+	}
+
+	castling := ""
+	for i := len(rank) - 1; i >= 0; i-- {
+		if rank[i] == 'R' {
+			castling += string(rune('A' + i))
+		}
+	}
+
+	return fmt.Sprintf("%s/pppppppp/8/8/8/8/PPPPPPPP/%s w %s%s - 0 1", strings.ToLower(rank), rank, castling, strings.ToLower(castling)), nil
+}
@@ -0,0 +1,149 @@
+package game
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+func TestChess960BackRank(t *testing.T) {
+	tests := []struct {
+		n    int
+		rank string
+	}{
+		{n: 0, rank: "BBQNNRKR"},
+		{n: 518, rank: "RNBQKBNR"},
+		{n: 959, rank: "RKRNNQBB"},
+	}
+
+	for _, test := range tests {
+		rank, err := Chess960BackRank(test.n)
+		if err != nil || rank != test.rank {
+			t.Errorf("%d: expected %s, got %s %v", test.n, test.rank, rank, err)
+		}
+	}
+
+	for _, n := range []int{-1, 960} {
+		if _, err := Chess960BackRank(n); err == nil {
+			t.Errorf("%d: expected an error", n)
+		}
+	}
+}
+
+func TestChess960BackRankRules(t *testing.T) {
+	ranks := map[string]bool{}
+	for n := 0; n < 960; n++ {
+		rank, err := Chess960BackRank(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ranks[rank] = true
+
+		bishops := []int{strings.Index(rank, "B"), strings.LastIndex(rank, "B")}
+		if bishops[0]%2 == bishops[1]%2 {
+			t.Errorf("%d: bishops of %s are on squares of the same color", n, rank)
+		}
+		king := strings.Index(rank, "K")
+		if king < strings.Index(rank, "R") || king > strings.LastIndex(rank, "R") {
+			t.Errorf("%d: king of %s is not between the rooks", n, rank)
+		}
+	}
+
+	if len(ranks) != 960 {
+		t.Errorf("expected 960 different positions, got %d", len(ranks))
+	}
+}
+
+func TestChess960FEN(t *testing.T) {
+	fen, err := Chess960FEN(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fen != "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w HFhf - 0 1" {
+		t.Errorf("unexpected FEN %s", fen)
+	}
+
+	for n := 0; n < 960; n++ {
+		fen, _ := Chess960FEN(n)
+		if _, err := chess.FEN(fen); err != nil {
+			t.Errorf("%d: invalid FEN %s: %v", n, fen, err)
+		}
+	}
+}
+
+func TestChess960Castling(t *testing.T) {
+	tests := []struct {
+		fen    string
+		castle string
+		after  string
+	}{
+		// the king is next to the rook
+		{fen: "1r4kr/pppppppp/8/8/8/8/PPPPPPPP/1R4KR w HBhb - 0 1", castle: "O-O", after: "1r4kr/pppppppp/8/8/8/8/PPPPPPPP/1R3RK1 b hb"},
+		// the king moves across the queen side
+		{fen: "1r4kr/pppppppp/8/8/8/8/PPPPPPPP/1R4KR w HBhb - 0 1", castle: "O-O-O", after: "1r4kr/pppppppp/8/8/8/8/PPPPPPPP/2KR3R b hb"},
+		// the king moves to the square of the rook
+		{fen: "1r3kr1/pppppppp/8/8/8/8/PPPPPPPP/1R3KR1 w GBgb - 0 1", castle: "O-O", after: "1r3kr1/pppppppp/8/8/8/8/PPPPPPPP/1R3RK1 b gb"},
+		// the standard position keeps the standard encoding
+		{fen: "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", castle: "O-O-O", after: "r3k2r/8/8/8/8/8/8/2KR3R b kq"},
+	}
+
+	for _, test := range tests {
+		fen, err := chess.FEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := chess.NewGame(fen)
+		if err := g.MoveStr(test.castle); err != nil {
+			t.Errorf("%s: %s failed: %v", test.fen, test.castle, err)
+			continue
+		}
+		// the board, side to move and castling rights
+		if after := strings.Join(strings.Fields(g.Position().String())[:3], " "); after != test.after {
+			t.Errorf("%s: expected %s after %s, got %s", test.fen, test.after, test.castle, after)
+		}
+	}
+}
+
+func TestChess960CastlingBlocked(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+	}{
+		{name: "piece between", fen: "4k3/8/8/8/8/8/8/1RN3KR w B - 0 1"},
+		{name: "castled square attacked", fen: "2r1k3/8/8/8/8/8/8/1R4KR w B - 0 1"},
+		{name: "attack through the rook", fen: "4k3/8/8/8/8/8/8/qR4K1 w B - 0 1"},
+		{name: "in check", fen: "4k1r1/8/8/8/8/8/8/1R4KR w HB - 0 1"},
+	}
+
+	for _, test := range tests {
+		fen, err := chess.FEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, move := range chess.NewGame(fen).ValidMoves() {
+			if move.HasTag(chess.KingSideCastle) || move.HasTag(chess.QueenSideCastle) {
+				t.Errorf("%s: castling %s must not be possible", test.name, move)
+			}
+		}
+	}
+}
+
+func TestChess960CastlingRights(t *testing.T) {
+	fen, _ := chess.FEN("1r4kr/pppppppp/8/8/8/8/PPPPPPPP/1R4KR w HBhb - 0 1")
+	g := chess.NewGame(fen)
+
+	if err := g.MoveStr("Ra1"); err != nil {
+		t.Fatal(err)
+	}
+	if rights := g.Position().CastleRights().String(); rights != "Hhb" {
+		t.Errorf("expected the right of the moved rook to be removed, got %s", rights)
+	}
+
+	if err := g.MoveStr("Kf8"); err != nil {
+		t.Fatal(err)
+	}
+	if rights := g.Position().CastleRights().String(); rights != "H" {
+		t.Errorf("expected the rights of the moved king to be removed, got %s", rights)
+	}
+}
@@ -0,0 +1,396 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+type ClockMode string
+
+const (
+	// CLOCK_MODE_INCREMENT adds the bonus after every move (Fischer)
+	CLOCK_MODE_INCREMENT ClockMode = "increment"
+	// CLOCK_MODE_DELAY gives back the time used for a move up to the bonus (Bronstein)
+	CLOCK_MODE_DELAY ClockMode = "delay"
+)
+
+type TimeControlStage struct {
+	// Moves is the number of moves of this stage. 0 means the rest of the game.
+	Moves int
+	Time  time.Duration
+	Bonus time.Duration
+}
+
+type TimeControl struct {
+	Stages []TimeControlStage
+	Mode   ClockMode
+}
+
+type ClockState struct {
+	White   time.Duration
+	Black   time.Duration
+	Running chess.Color
+}
+
+// SearchTime are the times of the clock as used by the go command of UCI engines
+type SearchTime struct {
+	White          time.Duration
+	Black          time.Duration
+	WhiteIncrement time.Duration
+	BlackIncrement time.Duration
+	// MovesToGo is the number of moves of the color to move until the next stage or 0 for the rest of the game
+	MovesToGo int
+}
+
+type Clock struct {
+	control   TimeControl
+	remaining map[chess.Color]time.Duration
+	moves     map[chess.Color]int
+	running   chess.Color
+	started   time.Time
+	mutex     *sync.Mutex
+}
+
+// ParseTimeControl parses time controls in PGN notation (seconds), e.g.
+// "300" (sudden death), "300+2" or multiple stages like "40/5400+30:1800+30".
+func ParseTimeControl(s string, mode ClockMode) (*TimeControl, error) {
+	if mode == "" {
+		mode = CLOCK_MODE_INCREMENT
+	}
+	if mode != CLOCK_MODE_INCREMENT && mode != CLOCK_MODE_DELAY {
+		return nil, fmt.Errorf("unknown clock mode %s", mode)
+	}
+
+	tc := &TimeControl{
+		Mode: mode,
+	}
+
+	for _, stageString := range strings.Split(s, ":") {
+		stage := TimeControlStage{}
+
+		if split := strings.SplitN(stageString, "/", 2); len(split) == 2 {
+			moves, err := strconv.Atoi(split[0])
+			if err != nil || moves <= 0 {
+				return nil, fmt.Errorf("invalid number of moves in time control %s", s)
+			}
+			stage.Moves = moves
+			stageString = split[1]
+		}
+
+		split := strings.SplitN(stageString, "+", 2)
+		seconds, err := strconv.Atoi(split[0])
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("invalid time in time control %s", s)
+		}
+		stage.Time = time.Duration(seconds) * time.Second
+
+		if len(split) == 2 {
+			bonus, err := strconv.Atoi(split[1])
+			if err != nil || bonus < 0 {
+				return nil, fmt.Errorf("invalid bonus in time control %s", s)
+			}
+			stage.Bonus = time.Duration(bonus) * time.Second
+		}
+
+		tc.Stages = append(tc.Stages, stage)
+	}
+
+	for i, stage := range tc.Stages {
+		if stage.Moves == 0 && i != len(tc.Stages)-1 {
+			return nil, errors.New("only the last stage of a time control can be sudden death")
+		}
+	}
+
+	return tc, nil
+}
+
+// String returns the time control in PGN notation
+func (tc TimeControl) String() string {
+	stages := []string{}
+	for _, stage := range tc.Stages {
+		s := fmt.Sprintf("%d", int(stage.Time.Seconds()))
+		if stage.Moves > 0 {
+			s = fmt.Sprintf("%d/%s", stage.Moves, s)
+		}
+		if stage.Bonus > 0 {
+			s = fmt.Sprintf("%s+%d", s, int(stage.Bonus.Seconds()))
+		}
+		stages = append(stages, s)
+	}
+
+	return strings.Join(stages, ":")
+}
+
+func NewClock(control TimeControl) *Clock {
+	return &Clock{
+		control: control,
+		remaining: map[chess.Color]time.Duration{
+			chess.White: control.Stages[0].Time,
+			chess.Black: control.Stages[0].Time,
+		},
+		moves: map[chess.Color]int{
+			chess.White: 0,
+			chess.Black: 0,
+		},
+		running: chess.NoColor,
+		mutex:   &sync.Mutex{},
+	}
+}
+
+// Start starts the clock of the given color. The clock of the other color is stopped without applying any bonus.
+func (c *Clock) Start(color chess.Color) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.running == color {
+		return
+	}
+
+	c.stop()
+	c.running = color
+	c.started = time.Now()
+}
+
+// Stop stops the running clock without applying any bonus
+func (c *Clock) Stop() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.stop()
+}
+
+func (c *Clock) stop() {
+	if c.running == chess.NoColor {
+		return
+	}
+
+	c.remaining[c.running] = c.remaining[c.running] - time.Since(c.started)
+	c.running = chess.NoColor
+}
+
+// Press ends the move of the running color, applies bonus and next stages and returns the remaining time
+func (c *Clock) Press() time.Duration {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	color := c.running
+	if color == chess.NoColor {
+		return 0
+	}
+
+	elapsed := time.Since(c.started)
+	c.running = chess.NoColor
+
+	remaining := c.remaining[color] - elapsed
+	if remaining <= 0 {
+		c.remaining[color] = 0
+		return 0
+	}
+
+	stageIndex := c.stageIndex(c.moves[color])
+	stage := c.control.Stages[stageIndex]
+	switch c.control.Mode {
+	case CLOCK_MODE_DELAY:
+		if elapsed < stage.Bonus {
+			remaining = remaining + elapsed
+		} else {
+			remaining = remaining + stage.Bonus
+		}
+	default:
+		remaining = remaining + stage.Bonus
+	}
+
+	c.moves[color]++
+	remaining = remaining + c.stageTime(c.moves[color])
+
+	c.remaining[color] = remaining
+	return remaining
+}
+
+// stageIndex returns the stage for the given number of moves already played by a color
+func (c *Clock) stageIndex(moves int) int {
+	total := 0
+	for i, stage := range c.control.Stages {
+		if stage.Moves == 0 {
+			return i
+		}
+		total = total + stage.Moves
+		if moves < total {
+			return i
+		}
+	}
+
+	// the last stage is repeated
+	return len(c.control.Stages) - 1
+}
+
+// stageTime returns the time of the next stage if a color completed a stage with the given number of moves. A last
+// stage with a number of moves is repeated, e.g. "40/5400" adds 5400 seconds after every 40 moves.
+func (c *Clock) stageTime(moves int) time.Duration {
+	stages := c.control.Stages
+	last := stages[len(stages)-1]
+
+	total := 0
+	for i, stage := range stages[:len(stages)-1] {
+		total = total + stage.Moves
+		if moves == total {
+			return stages[i+1].Time
+		}
+	}
+
+	if last.Moves > 0 && moves > total && (moves-total)%last.Moves == 0 {
+		return last.Time
+	}
+	return 0
+}
+
+// Set sets the remaining time and the number of played moves of a color, e.g. when resuming a game
+func (c *Clock) Set(color chess.Color, remaining time.Duration, moves int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.remaining[color] = remaining
+	c.moves[color] = moves
+}
+
+func (c *Clock) Remaining(color chess.Color) time.Duration {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.remainingTime(color)
+}
+
+func (c *Clock) remainingTime(color chess.Color) time.Duration {
+	remaining := c.remaining[color]
+	if c.running == color {
+		remaining = remaining - time.Since(c.started)
+	}
+
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// Flagged returns the color whose time has run out or chess.NoColor
+func (c *Clock) Flagged() chess.Color {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, color := range []chess.Color{chess.White, chess.Black} {
+		if c.remainingTime(color) <= 0 {
+			return color
+		}
+	}
+
+	return chess.NoColor
+}
+
+// SearchTime returns the remaining times and bonuses of the current stages of both colors
+func (c *Clock) SearchTime() SearchTime {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	t := SearchTime{
+		White:          c.remainingTime(chess.White),
+		Black:          c.remainingTime(chess.Black),
+		WhiteIncrement: c.control.Stages[c.stageIndex(c.moves[chess.White])].Bonus,
+		BlackIncrement: c.control.Stages[c.stageIndex(c.moves[chess.Black])].Bonus,
+	}
+
+	if c.running != chess.NoColor {
+		t.MovesToGo = c.movesToGo(c.moves[c.running])
+	}
+	return t
+}
+
+// movesToGo returns the number of moves until the next stage or 0 if the current stage lasts for the rest of the game
+func (c *Clock) movesToGo(moves int) int {
+	for next := moves + 1; ; next++ {
+		if c.stageTime(next) > 0 {
+			return next - moves
+		}
+		if c.control.Stages[c.stageIndex(next)].Moves == 0 {
+			return 0
+		}
+	}
+}
+
+func (c *Clock) State() ClockState {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return ClockState{
+		White:   c.remainingTime(chess.White),
+		Black:   c.remainingTime(chess.Black),
+		Running: c.running,
+	}
+}
+
+// FormatClock formats a duration as used by the PGN [%clk] command
+func FormatClock(d time.Duration) string {
+	seconds := int(d.Seconds())
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, (seconds/60)%60, seconds%60)
+}
+
+// ParseClockComment parses a PGN [%clk] command, e.g. "[%clk 1:05:00]"
+func ParseClockComment(comment string) (time.Duration, bool) {
+	if !strings.HasPrefix(comment, "[%clk ") || !strings.HasSuffix(comment, "]") {
+		return 0, false
+	}
+
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(comment, "[%clk "), "]"), ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+
+	d := time.Duration(0)
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		value, err := strconv.ParseFloat(parts[i], 64)
+		if err != nil {
+			return 0, false
+		}
+		d = d + time.Duration(value*float64(unit))
+	}
+
+	return d, true
+}
+
+// hasMatingMaterial returns false if the given color can not checkmate by any series of legal moves, which makes a
+// game lost on time a draw. The pieces of the opponent count as they may block the escape squares of their king.
+func hasMatingMaterial(board *chess.Board, color chess.Color) bool {
+	own := map[chess.PieceType]int{}
+	other := map[chess.PieceType]int{}
+	// bishopSquares are the square colors of all bishops on the board, true for light squares
+	bishopSquares := map[bool]bool{}
+	for sq, piece := range board.SquareMap() {
+		if piece.Color() == color {
+			own[piece.Type()]++
+		} else {
+			other[piece.Type()]++
+		}
+		if piece.Type() == chess.Bishop {
+			bishopSquares[(int(sq.File())+int(sq.Rank()))%2 == 1] = true
+		}
+	}
+
+	switch {
+	case own[chess.Pawn]+own[chess.Rook]+own[chess.Queen] > 0:
+		return true
+	case own[chess.Knight] > 0 && own[chess.Knight]+own[chess.Bishop] >= 2:
+		return true
+	case own[chess.Knight] == 1:
+		// the king of the opponent has to be blocked by a piece that can not capture or block the knight in time
+		return other[chess.Pawn]+other[chess.Knight]+other[chess.Bishop]+other[chess.Rook] > 0
+	case own[chess.Bishop] > 0:
+		// bishops on one square color never attack the other squares
+		return len(bishopSquares) == 2 || other[chess.Pawn]+other[chess.Knight] > 0
+	}
+	return false
+}
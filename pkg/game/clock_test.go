@@ -0,0 +1,213 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+func TestParseTimeControl(t *testing.T) {
+	tests := []struct {
+		input  string
+		stages []TimeControlStage
+		err    bool
+	}{
+		{input: "300", stages: []TimeControlStage{{Time: 300 * time.Second}}},
+		{input: "300+2", stages: []TimeControlStage{{Time: 300 * time.Second, Bonus: 2 * time.Second}}},
+		{input: "40/5400", stages: []TimeControlStage{{Moves: 40, Time: 5400 * time.Second}}},
+		{input: "40/5400+30:1800+30", stages: []TimeControlStage{
+			{Moves: 40, Time: 5400 * time.Second, Bonus: 30 * time.Second},
+			{Time: 1800 * time.Second, Bonus: 30 * time.Second},
+		}},
+		{input: "", err: true},
+		{input: "abc", err: true},
+		{input: "0", err: true},
+		{input: "300+x", err: true},
+		{input: "0/300", err: true},
+		{input: "300:40/300", err: true},
+	}
+
+	for _, test := range tests {
+		tc, err := ParseTimeControl(test.input, "")
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected an error", test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", test.input, err)
+			continue
+		}
+
+		if len(tc.Stages) != len(test.stages) {
+			t.Errorf("%q: expected %d stages, got %d", test.input, len(test.stages), len(tc.Stages))
+			continue
+		}
+		for i, stage := range test.stages {
+			if tc.Stages[i] != stage {
+				t.Errorf("%q: expected stage %d to be %+v, got %+v", test.input, i, stage, tc.Stages[i])
+			}
+		}
+		if tc.String() != test.input {
+			t.Errorf("%q: formatted as %q", test.input, tc.String())
+		}
+	}
+}
+
+func TestParseTimeControlMode(t *testing.T) {
+	if _, err := ParseTimeControl("300", "hourglass"); err == nil {
+		t.Error("expected an error for an unknown clock mode")
+	}
+
+	tc, err := ParseTimeControl("300+5", CLOCK_MODE_DELAY)
+	if err != nil || tc.Mode != CLOCK_MODE_DELAY {
+		t.Errorf("expected delay mode, got %v %v", tc, err)
+	}
+}
+
+// press plays a move of the color without using time and returns the remaining time
+func press(c *Clock, color chess.Color) time.Duration {
+	c.Start(color)
+	return c.Press()
+}
+
+// assertAround fails if the remaining time differs from the expected time by more than the time the test needs
+func assertAround(t *testing.T, move int, expected, actual time.Duration) {
+	t.Helper()
+	if actual > expected || actual < expected-time.Second {
+		t.Errorf("move %d: expected %s, got %s", move, expected, actual)
+	}
+}
+
+func TestClockPressIncrement(t *testing.T) {
+	tc, _ := ParseTimeControl("300+2", "")
+	c := NewClock(*tc)
+
+	for move := 1; move <= 3; move++ {
+		assertAround(t, move, 300*time.Second+time.Duration(move)*2*time.Second, press(c, chess.White))
+	}
+	assertAround(t, 1, 302*time.Second, press(c, chess.Black))
+}
+
+func TestClockPressDelay(t *testing.T) {
+	tc, _ := ParseTimeControl("300+5", CLOCK_MODE_DELAY)
+	c := NewClock(*tc)
+
+	// the time used for a move is given back, but no more
+	assertAround(t, 1, 300*time.Second, press(c, chess.White))
+}
+
+func TestClockPressStages(t *testing.T) {
+	tests := []struct {
+		control string
+		// expected are the remaining times after each move
+		expected []time.Duration
+	}{
+		{control: "2/60:30", expected: []time.Duration{60 * time.Second, 90 * time.Second, 90 * time.Second, 90 * time.Second}},
+		{control: "2/60", expected: []time.Duration{60 * time.Second, 120 * time.Second, 120 * time.Second, 180 * time.Second, 180 * time.Second}},
+		{control: "1/60:2/30", expected: []time.Duration{90 * time.Second, 90 * time.Second, 120 * time.Second, 120 * time.Second, 150 * time.Second}},
+		{control: "2/60+1:30+2", expected: []time.Duration{61 * time.Second, 92 * time.Second, 94 * time.Second}},
+	}
+
+	for _, test := range tests {
+		tc, err := ParseTimeControl(test.control, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := NewClock(*tc)
+
+		for i, expected := range test.expected {
+			remaining := press(c, chess.White)
+			if remaining > expected || remaining < expected-time.Second {
+				t.Errorf("%s: expected %s after move %d, got %s", test.control, expected, i+1, remaining)
+			}
+		}
+	}
+}
+
+func TestClockSearchTime(t *testing.T) {
+	tc, _ := ParseTimeControl("2/60+1:30+3", "")
+	c := NewClock(*tc)
+
+	c.Start(chess.White)
+	st := c.SearchTime()
+	if st.MovesToGo != 2 || st.WhiteIncrement != time.Second || st.BlackIncrement != time.Second {
+		t.Errorf("unexpected search time %+v", st)
+	}
+	c.Press()
+
+	c.Start(chess.White)
+	if st := c.SearchTime(); st.MovesToGo != 1 {
+		t.Errorf("expected 1 move to go, got %+v", st)
+	}
+	c.Press()
+
+	c.Start(chess.White)
+	if st := c.SearchTime(); st.MovesToGo != 0 || st.WhiteIncrement != 3*time.Second || st.BlackIncrement != time.Second {
+		t.Errorf("expected the sudden death stage, got %+v", st)
+	}
+}
+
+func TestClockSetAndFlag(t *testing.T) {
+	tc, _ := ParseTimeControl("60", "")
+	c := NewClock(*tc)
+
+	if c.Flagged() != chess.NoColor {
+		t.Error("no color should be flagged")
+	}
+	c.Set(chess.Black, 0, 10)
+	if c.Flagged() != chess.Black {
+		t.Error("black should be flagged")
+	}
+}
+
+func TestClockComment(t *testing.T) {
+	d, ok := ParseClockComment("[%clk " + FormatClock(65*time.Minute+3*time.Second) + "]")
+	if !ok || d != 65*time.Minute+3*time.Second {
+		t.Errorf("expected 1:05:03, got %s %v", d, ok)
+	}
+
+	if _, ok := ParseClockComment("Blunder"); ok {
+		t.Error("a comment without clock must not be parsed")
+	}
+}
+
+func TestHasMatingMaterial(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		white bool
+	}{
+		{name: "bare king", fen: "4k3/pppppppp/8/8/8/8/8/4K3 w - - 0 1", white: false},
+		{name: "pawn", fen: "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", white: true},
+		{name: "rook", fen: "4k3/8/8/8/8/8/8/R3K3 w - - 0 1", white: true},
+		{name: "queen", fen: "4k3/8/8/8/8/8/8/3QK3 w - - 0 1", white: true},
+		{name: "knight against bare king", fen: "4k3/8/8/8/8/8/8/1N2K3 w - - 0 1", white: false},
+		{name: "knight against pawn", fen: "4k3/7p/8/8/8/8/8/1N2K3 w - - 0 1", white: true},
+		{name: "knight against rook", fen: "r3k3/8/8/8/8/8/8/1N2K3 w - - 0 1", white: true},
+		{name: "knight against queen", fen: "3qk3/8/8/8/8/8/8/1N2K3 w - - 0 1", white: false},
+		{name: "two knights", fen: "4k3/8/8/8/8/8/8/1N2K1N1 w - - 0 1", white: true},
+		{name: "knight and bishop", fen: "4k3/8/8/8/8/8/8/1N2KB2 w - - 0 1", white: true},
+		{name: "bishop against bare king", fen: "4k3/8/8/8/8/8/8/4KB2 w - - 0 1", white: false},
+		{name: "bishop against pawn", fen: "4k3/7p/8/8/8/8/8/4KB2 w - - 0 1", white: true},
+		{name: "bishop against knight", fen: "1n2k3/8/8/8/8/8/8/4KB2 w - - 0 1", white: true},
+		{name: "bishop against rook", fen: "r3k3/8/8/8/8/8/8/4KB2 w - - 0 1", white: false},
+		{name: "bishop against bishop on the same color", fen: "4kb2/8/8/8/8/8/8/2B1K3 w - - 0 1", white: false},
+		{name: "bishop against bishop on the other color", fen: "2b1k3/8/8/8/8/8/8/2B1K3 w - - 0 1", white: true},
+		{name: "bishops on the same color", fen: "4k3/8/8/8/8/4B3/8/2B1K3 w - - 0 1", white: false},
+		{name: "bishops on both colors", fen: "4k3/8/8/8/8/8/8/2B1KB2 w - - 0 1", white: true},
+	}
+
+	for _, test := range tests {
+		fen, err := chess.FEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		board := chess.NewGame(fen).Position().Board()
+		if actual := hasMatingMaterial(board, chess.White); actual != test.white {
+			t.Errorf("%s: expected mating material %v, got %v", test.name, test.white, actual)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package game
+
+import (
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+type SquareDiff struct {
+	Square   chess.Square
+	Expected chess.Piece
+	Actual   chess.Piece
+}
+
+// BoardDiff returns all squares whose pieces differ between the expected and the actual board
+func BoardDiff(expected, actual chess.Board) []SquareDiff {
+	diff := []SquareDiff{}
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		if expected.Piece(sq) != actual.Piece(sq) {
+			diff = append(diff, SquareDiff{
+				Square:   sq,
+				Expected: expected.Piece(sq),
+				Actual:   actual.Piece(sq),
+			})
+		}
+	}
+
+	return diff
+}
+
+func sameDiff(a, b []SquareDiff) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PieceString returns the FEN character of a piece or an empty string for no piece
+func PieceString(p chess.Piece) string {
+	if p.Color() == chess.White {
+		return strings.ToUpper(p.Type().String())
+	}
+	return p.Type().String()
+}
@@ -0,0 +1,114 @@
+package game
+
+import (
+	"errors"
+	"log"
+
+	"github.com/notnil/chess"
+)
+
+var ErrNoDrawOffer = errors.New("no draw offered")
+var ErrDrawDeclined = errors.New("draw declined")
+
+// DrawOffer is a pending draw offer of a player
+type DrawOffer struct {
+	By chess.Color
+}
+
+// OfferDraw offers the opponent of the given color a draw. If no color is given, a human playing against a bot or
+// the player who made the last move offers the draw.
+func (g *Game) OfferDraw(by chess.Color) error {
+	return g.send(event{eventType: EVENT_DRAW_OFFER, color: by})
+}
+
+// AnswerDraw accepts or declines the pending draw offer
+func (g *Game) AnswerDraw(accept bool) error {
+	eventType := EVENT_DRAW_DECLINE
+	if accept {
+		eventType = EVENT_DRAW_ACCEPT
+	}
+
+	return g.send(event{eventType: eventType})
+}
+
+func (g *Game) offerDraw(by chess.Color) error {
+	if g.drawOffer != nil {
+		return errors.New("a draw is already offered")
+	}
+
+	if by == chess.NoColor {
+		by = g.requester()
+	}
+
+	// bots play on
+	if opponent := g.player(by.Other()); opponent.IsBot() {
+		log.Printf("%s declined the draw offer of %s", opponent.Name(), by.Name())
+		return ErrDrawDeclined
+	}
+
+	g.drawOffer = &DrawOffer{By: by}
+	log.Printf("%s offers a draw", by.Name())
+	g.notify(UIAction{Event: EVENT_DRAW_OFFER})
+	return nil
+}
+
+func (g *Game) answerDraw(accept bool) error {
+	offer := g.drawOffer
+	if offer == nil {
+		return ErrNoDrawOffer
+	}
+	g.drawOffer = nil
+
+	if !accept {
+		log.Printf("Draw offer of %s declined", offer.By.Name())
+		g.notify(UIAction{Event: EVENT_DRAW_DECLINE})
+		return nil
+	}
+
+	return g.draw()
+}
+
+// withdrawDrawOffer drops a pending offer, e.g. after a move was made
+func (g *Game) withdrawDrawOffer() {
+	if g.drawOffer != nil {
+		log.Printf("Draw offer of %s withdrawn", g.drawOffer.By.Name())
+		g.drawOffer = nil
+	}
+}
+
+// ClaimableDraws returns the draws a player can claim in the current position
+func ClaimableDraws(g *chess.Game) []chess.Method {
+	claims := []chess.Method{}
+	if g.Outcome() != chess.NoOutcome {
+		return claims
+	}
+
+	for _, method := range g.EligibleDraws() {
+		if method == chess.ThreefoldRepetition || method == chess.FiftyMoveRule {
+			claims = append(claims, method)
+		}
+	}
+
+	return claims
+}
+
+func DrawMethodName(method chess.Method) string {
+	switch method {
+	case chess.DrawOffer:
+		return "agreement"
+	case chess.Stalemate:
+		return "stalemate"
+	case chess.ThreefoldRepetition:
+		return "threefold repetition"
+	case chess.FivefoldRepetition:
+		return "fivefold repetition"
+	case chess.FiftyMoveRule:
+		return "fifty-move rule"
+	case chess.SeventyFiveMoveRule:
+		return "seventy-five-move rule"
+	case chess.InsufficientMaterial:
+		return "insufficient material"
+	}
+
+	return method.String()
+}
@@ -0,0 +1,177 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// play makes the moves in algebraic notation or fails the test
+func play(t *testing.T, g *chess.Game, moves ...string) {
+	t.Helper()
+	for _, move := range moves {
+		if err := g.MoveStr(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestClaimableDraws(t *testing.T) {
+	repetition := []string{"Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1", "Ng8"}
+	fifty, _ := chess.FEN("4k3/8/8/8/8/8/8/R3K3 w - - 99 80")
+
+	tests := []struct {
+		name     string
+		game     *chess.Game
+		moves    []string
+		expected []chess.Method
+	}{
+		{name: "start position", game: chess.NewGame(), expected: []chess.Method{}},
+		{name: "twofold repetition", game: chess.NewGame(), moves: repetition[:4], expected: []chess.Method{}},
+		{name: "threefold repetition", game: chess.NewGame(), moves: repetition, expected: []chess.Method{chess.ThreefoldRepetition}},
+		{name: "49 moves", game: chess.NewGame(fifty), expected: []chess.Method{}},
+		{name: "fifty moves", game: chess.NewGame(fifty), moves: []string{"Ra2"}, expected: []chess.Method{chess.FiftyMoveRule}},
+	}
+
+	for _, test := range tests {
+		play(t, test.game, test.moves...)
+		if claims := ClaimableDraws(test.game); !reflect.DeepEqual(claims, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, claims)
+		}
+	}
+}
+
+func TestClaimableDrawsFinishedGame(t *testing.T) {
+	g := chess.NewGame()
+	play(t, g, "Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1", "Ng8")
+	if err := g.Draw(chess.ThreefoldRepetition); err != nil {
+		t.Fatal(err)
+	}
+
+	if claims := ClaimableDraws(g); len(claims) != 0 {
+		t.Errorf("a finished game has no claims, got %v", claims)
+	}
+}
+
+func TestAutomaticDraws(t *testing.T) {
+	insufficient, _ := chess.FEN("4k3/8/8/8/8/8/8/4KB2 b - - 0 1")
+	g := chess.NewGame(insufficient)
+	play(t, g, "Kd8")
+	if g.Outcome() != chess.Draw || DrawMethodName(g.Method()) != "insufficient material" {
+		t.Errorf("expected a draw by insufficient material, got %s %s", g.Outcome(), g.Method())
+	}
+
+	g = chess.NewGame()
+	for i := 0; i < 4; i++ {
+		play(t, g, "Nf3", "Nf6", "Ng1", "Ng8")
+	}
+	if g.Outcome() != chess.Draw || DrawMethodName(g.Method()) != "fivefold repetition" {
+		t.Errorf("expected a draw by fivefold repetition, got %s %s", g.Outcome(), g.Method())
+	}
+}
+
+// testBot is a test player that is a bot
+type testBot struct {
+	*testPlayer
+}
+
+func (p testBot) IsBot() bool {
+	return true
+}
+
+// events collects the events of a game
+type events chan EventType
+
+func (e events) Render(g chess.Game, action UIAction) {
+	e <- action.Event
+}
+
+// expectEvent fails unless the game notifies the event within a second
+func expectEvent(t *testing.T, e events, expected EventType) {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case event := <-e:
+			if event == expected {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("expected the event %s", expected)
+		}
+	}
+}
+
+func TestDrawOffer(t *testing.T) {
+	g := NewGame(newTestPlayer("black"), newTestPlayer("white"))
+	g.game = chess.NewGame()
+	play(t, g.game, "e4")
+
+	// the player who made the last move offers the draw
+	if err := g.offerDraw(chess.NoColor); err != nil {
+		t.Fatal(err)
+	}
+	if g.drawOffer == nil || g.drawOffer.By != chess.White {
+		t.Fatalf("expected a draw offer of white, got %v", g.drawOffer)
+	}
+	if err := g.offerDraw(chess.Black); err == nil {
+		t.Error("expected an error for a second offer")
+	}
+
+	if err := g.answerDraw(false); err != nil {
+		t.Fatal(err)
+	}
+	if g.drawOffer != nil || g.game.Outcome() != chess.NoOutcome {
+		t.Fatalf("expected the offer to be declined, got %v %s", g.drawOffer, g.game.Outcome())
+	}
+	if err := g.answerDraw(true); err != ErrNoDrawOffer {
+		t.Errorf("expected %v, got %v", ErrNoDrawOffer, err)
+	}
+
+	if err := g.offerDraw(chess.Black); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.answerDraw(true); err != nil {
+		t.Fatal(err)
+	}
+	if g.game.Outcome() != chess.Draw || g.game.Method() != chess.DrawOffer {
+		t.Errorf("expected a draw by agreement, got %s by %s", g.game.Outcome(), g.game.Method())
+	}
+}
+
+func TestDrawOfferDeclinedByBot(t *testing.T) {
+	g := NewGame(testBot{newTestPlayer("black")}, newTestPlayer("white"))
+	g.game = chess.NewGame()
+
+	// the human offers the draw
+	if err := g.offerDraw(chess.NoColor); err != ErrDrawDeclined {
+		t.Errorf("expected %v, got %v", ErrDrawDeclined, err)
+	}
+	if g.drawOffer != nil {
+		t.Errorf("expected no pending offer, got %v", g.drawOffer)
+	}
+}
+
+func TestDrawOfferWithdrawnByMove(t *testing.T) {
+	white, black := newTestPlayer("white"), newTestPlayer("black")
+	e := make(events, 64)
+	g := NewGame(black, white, e)
+	go g.Start("")
+	expectEvent(t, e, EVENT_STARTED)
+
+	if err := g.OfferDraw(chess.Black); err != nil {
+		t.Fatal(err)
+	}
+	white.moves <- "e4"
+	expectEvent(t, e, EVENT_MOVE)
+	if err := g.AnswerDraw(true); err != ErrNoDrawOffer {
+		t.Errorf("expected the offer to be withdrawn, got %v", err)
+	}
+
+	if err := g.Resign(); err != nil {
+		t.Fatal(err)
+	}
+	assertEnded(t, g, white, black)
+}
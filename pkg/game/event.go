@@ -0,0 +1,108 @@
+package game
+
+import (
+	"errors"
+
+	"github.com/notnil/chess"
+)
+
+type EventType string
+
+const (
+	EVENT_STARTED            EventType = "started"
+	EVENT_MOVE               EventType = "move"
+	EVENT_EVALUATION         EventType = "evaluation"
+	EVENT_UNDO               EventType = "undo"
+	EVENT_TAKEBACK_REQUEST   EventType = "takeback_request"
+	EVENT_TAKEBACK_ACCEPT    EventType = "takeback_accept"
+	EVENT_TAKEBACK_DECLINE   EventType = "takeback_decline"
+	EVENT_BOARD              EventType = "board"
+	EVENT_DRAW               EventType = "draw"
+	EVENT_DRAW_OFFER         EventType = "draw_offer"
+	EVENT_DRAW_ACCEPT        EventType = "draw_accept"
+	EVENT_DRAW_DECLINE       EventType = "draw_decline"
+	EVENT_CLAIM_DRAW         EventType = "claim_draw"
+	EVENT_RESIGN             EventType = "resign"
+	EVENT_TIMEOUT            EventType = "timeout"
+	EVENT_PAUSE              EventType = "pause"
+	EVENT_CONTINUE           EventType = "continue"
+	EVENT_ABORT              EventType = "abort"
+	EVENT_PLAYER_SUBSTITUTED EventType = "player_substituted"
+	EVENT_ENDED              EventType = "ended"
+)
+
+var ErrGameNotRunning = errors.New("game is not running")
+
+// event is handled by the game loop, which is the only goroutine changing a running game
+type event struct {
+	eventType EventType
+	// request identifies the move request a move belongs to so moves of interrupted requests are dropped
+	request int
+	move    *chess.Move
+	err     error
+	n       int
+	color   chess.Color
+	method  chess.Method
+	board   *chess.Board
+	discard bool
+	reply   chan error
+}
+
+// post hands an event to the game loop and returns false if the game has already ended
+func (g *Game) post(e event) bool {
+	select {
+	case g.events <- e:
+		return true
+	case <-g.done:
+		return false
+	}
+}
+
+// send hands an event to the game loop and waits until it was handled
+func (g *Game) send(e event) error {
+	e.reply = make(chan error, 1)
+	if !g.post(e) {
+		return ErrGameNotRunning
+	}
+
+	return <-e.reply
+}
+
+func (g *Game) handle(e event) {
+	var err error
+
+	switch e.eventType {
+	case EVENT_MOVE:
+		g.handleMove(e)
+	case EVENT_UNDO:
+		err = g.undoMoves(e.n)
+	case EVENT_TAKEBACK_REQUEST:
+		err = g.requestTakeback(e.color, 0)
+	case EVENT_BOARD:
+		g.boardChanged(*e.board)
+	case EVENT_TAKEBACK_ACCEPT:
+		err = g.answerTakeback(true)
+	case EVENT_TAKEBACK_DECLINE:
+		err = g.answerTakeback(false)
+	case EVENT_DRAW_OFFER:
+		err = g.offerDraw(e.color)
+	case EVENT_DRAW_ACCEPT:
+		err = g.answerDraw(true)
+	case EVENT_DRAW_DECLINE:
+		err = g.answerDraw(false)
+	case EVENT_CLAIM_DRAW:
+		err = g.claimDraw(e.method)
+	case EVENT_RESIGN:
+		err = g.resign()
+	case EVENT_PAUSE:
+		err = g.pause()
+	case EVENT_CONTINUE:
+		err = g.unpause()
+	case EVENT_ABORT:
+		g.abort(e.discard)
+	}
+
+	if e.reply != nil {
+		e.reply <- err
+	}
+}
@@ -0,0 +1,171 @@
+package game
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/notnil/chess"
+)
+
+// Execution is a bot move that still has to be made on the physical board
+type Execution struct {
+	Move        *chess.Move
+	Instruction string
+	// Mistake is the move of the opponent made on the board before the bot move was executed
+	Mistake string
+}
+
+// expectExecution waits for the move of a bot to be made on the board
+func (g *Game) expectExecution(color chess.Color, move *chess.Move) {
+	g.execution = nil
+	if !g.boardEnabled || g.board == nil || !g.player(color).IsBot() {
+		return
+	}
+
+	positions := g.game.Positions()
+	g.execution = &Execution{
+		Move:        move,
+		Instruction: moveInstruction(positions[len(positions)-2], move),
+	}
+	log.Printf("Awaiting execution of %s on the board: %s", move, g.execution.Instruction)
+}
+
+// checkExecution ends the execution of a bot move once the board shows it and flags moves of the opponent made before
+func (g *Game) checkExecution() {
+	if g.execution == nil || g.board == nil {
+		return
+	}
+
+	if g.boardMatches(g.game.Position().Board()) {
+		log.Printf("%s executed on the board", g.execution.Move)
+		g.execution = nil
+		g.notify(UIAction{Event: EVENT_BOARD})
+		return
+	}
+
+	mistake := g.earlyMove()
+	if mistake == g.execution.Mistake {
+		return
+	}
+
+	g.execution.Mistake = mistake
+	if mistake != "" {
+		log.Printf("%s was made on the board before %s was executed", mistake, g.execution.Move)
+	}
+	g.notify(UIAction{Event: EVENT_BOARD})
+}
+
+// earlyMove returns the move of the opponent the board shows apart from the squares of the bot move or an empty string
+func (g *Game) earlyMove() string {
+	positions := g.game.Positions()
+	before := positions[len(positions)-2].Board()
+	position := g.game.Position()
+
+	botSquares := map[chess.Square]bool{}
+	for _, d := range BoardDiff(*before, *position.Board()) {
+		botSquares[d.Square] = true
+	}
+
+	for _, move := range position.ValidMoves() {
+		after := position.Update(move).Board()
+
+		placed, matches := false, true
+		for sq := chess.A1; sq <= chess.H8; sq++ {
+			if botSquares[sq] {
+				continue
+			}
+			if after.Piece(sq) != chess.NoPiece && after.Piece(sq) != position.Board().Piece(sq) {
+				// a lifted piece alone is no move
+				placed = true
+			}
+			if after.Piece(sq) != g.board.Piece(sq) {
+				matches = false
+				break
+			}
+		}
+
+		if placed && matches {
+			return chess.AlgebraicNotation{}.Encode(position, move)
+		}
+	}
+
+	return ""
+}
+
+// moveInstruction describes how to make a move on the board
+func moveInstruction(pos *chess.Position, move *chess.Move) string {
+	board := pos.Board()
+	piece := board.Piece(move.S1())
+	name := pieceName(piece.Type())
+
+	switch {
+	case move.HasTag(chess.KingSideCastle) || move.HasTag(chess.QueenSideCastle):
+		side := "king side"
+		if move.HasTag(chess.QueenSideCastle) {
+			side = "queen side"
+		}
+
+		kingTo, rookFrom, rookTo := castlingSquares(pos, move)
+		return fmt.Sprintf("Castle %s: move the king from %s to %s and the rook from %s to %s", side, move.S1(), kingTo, rookFrom, rookTo)
+
+	case move.HasTag(chess.EnPassant):
+		captured := chess.NewSquare(move.S2().File(), move.S1().Rank())
+		return fmt.Sprintf("Capture en passant: move the pawn from %s to %s and remove the pawn on %s", move.S1(), move.S2(), captured)
+
+	case move.Promo() != chess.NoPieceType:
+		instruction := fmt.Sprintf("Promote: move the pawn from %s to %s", move.S1(), move.S2())
+		if move.HasTag(chess.Capture) {
+			instruction = fmt.Sprintf("Promote: capture the %s on %s with the pawn from %s", pieceName(board.Piece(move.S2()).Type()), move.S2(), move.S1())
+		}
+		return fmt.Sprintf("%s and replace it with a %s", instruction, pieceName(move.Promo()))
+
+	case move.HasTag(chess.Capture):
+		return fmt.Sprintf("Capture the %s on %s with the %s from %s", pieceName(board.Piece(move.S2()).Type()), move.S2(), name, move.S1())
+	}
+
+	return fmt.Sprintf("Move the %s from %s to %s", name, move.S1(), move.S2())
+}
+
+// castlingSquares returns the square the king moves to and the squares the rook moves from and to when castling. In
+// Chess960 the king may stay on its square or move to the square of the rook.
+func castlingSquares(pos *chess.Position, move *chess.Move) (chess.Square, chess.Square, chess.Square) {
+	kingTo, from, to := move.S1(), chess.NoSquare, chess.NoSquare
+	after := pos.Update(move).Board()
+
+	for _, d := range BoardDiff(*pos.Board(), *after) {
+		if d.Actual.Type() == chess.King {
+			kingTo = d.Square
+		}
+		if d.Expected.Type() == chess.Rook && d.Actual.Type() != chess.Rook {
+			from = d.Square
+		}
+		if d.Actual.Type() == chess.Rook && d.Expected.Type() != chess.Rook {
+			to = d.Square
+		}
+	}
+	if from == chess.NoSquare {
+		// the rook stays on its square
+		from, to = move.S2(), move.S2()
+	}
+
+	return kingTo, from, to
+}
+
+func pieceName(t chess.PieceType) string {
+	switch t {
+	case chess.King:
+		return "king"
+	case chess.Queen:
+		return "queen"
+	case chess.Rook:
+		return "rook"
+	case chess.Bishop:
+		return "bishop"
+	case chess.Knight:
+		return "knight"
+	case chess.Pawn:
+		return "pawn"
+	}
+
+	return "piece"
+}
@@ -0,0 +1,96 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+func TestMoveInstruction(t *testing.T) {
+	tests := []struct {
+		name        string
+		fen         string
+		move        string
+		instruction string
+	}{
+		{name: "move", fen: startFEN, move: "Nf3",
+			instruction: "Move the knight from g1 to f3"},
+		{name: "capture", fen: "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", move: "exd5",
+			instruction: "Capture the pawn on d5 with the pawn from e4"},
+		{name: "castle king side", fen: "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", move: "O-O",
+			instruction: "Castle king side: move the king from e1 to g1 and the rook from h1 to f1"},
+		{name: "castle queen side", fen: "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", move: "O-O-O",
+			instruction: "Castle queen side: move the king from e8 to c8 and the rook from a8 to d8"},
+		{name: "castle to the square of the rook", fen: "1r3kr1/pppppppp/8/8/8/8/PPPPPPPP/1R3KR1 w GBgb - 0 1", move: "O-O",
+			instruction: "Castle king side: move the king from f1 to g1 and the rook from g1 to f1"},
+		{name: "en passant", fen: "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 2", move: "exd6",
+			instruction: "Capture en passant: move the pawn from e5 to d6 and remove the pawn on d5"},
+		{name: "promotion", fen: "4k3/P7/8/8/8/8/8/4K3 w - - 0 1", move: "a8=Q",
+			instruction: "Promote: move the pawn from a7 to a8 and replace it with a queen"},
+		{name: "promotion with capture", fen: "1n2k3/P7/8/8/8/8/8/4K3 w - - 0 1", move: "axb8=N",
+			instruction: "Promote: capture the knight on b8 with the pawn from a7 and replace it with a knight"},
+	}
+
+	for _, test := range tests {
+		fen, err := chess.FEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		position := chess.NewGame(fen).Position()
+		move, err := chess.AlgebraicNotation{}.Decode(position, test.move)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if instruction := moveInstruction(position, move); instruction != test.instruction {
+			t.Errorf("%s: expected %q, got %q", test.name, test.instruction, instruction)
+		}
+	}
+}
+
+func TestEarlyMove(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		// bot is the move of the bot that is not executed on the board yet
+		bot   string
+		board string
+		early string
+	}{
+		{name: "move", fen: startFEN, bot: "e4",
+			board: "rnbqkbnr/pppp1ppp/8/4p3/8/8/PPPPPPPP/RNBQKBNR", early: "e5"},
+		{name: "lifted piece", fen: startFEN, bot: "e4",
+			board: "rnbqkbnr/pppp1ppp/8/8/8/8/PPPPPPPP/RNBQKBNR", early: ""},
+		{name: "castling", fen: "r3k2r/pppppppp/8/8/8/8/PPPPPPPP/R3K2R w KQkq - 0 1", bot: "a3",
+			board: "r4rk1/pppppppp/8/8/8/8/PPPPPPPP/R3K2R", early: "O-O"},
+		{name: "half castled", fen: "r3k2r/pppppppp/8/8/8/8/PPPPPPPP/R3K2R w KQkq - 0 1", bot: "a3",
+			board: "r5kr/pppppppp/8/8/8/8/PPPPPPPP/R3K2R", early: ""},
+		{name: "en passant", fen: "4k3/8/8/8/4p3/8/3P4/4K3 w - - 0 1", bot: "d4",
+			board: "4k3/8/8/8/8/3p4/3P4/4K3", early: "exd3"},
+		{name: "promotion", fen: "4k3/8/8/8/8/8/p7/4K3 w - - 0 1", bot: "Ke2",
+			board: "4k3/8/8/8/8/8/8/q3K3", early: "a1=Q"},
+		{name: "bot move executed", fen: startFEN, bot: "e4",
+			board: "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR", early: ""},
+	}
+
+	for _, test := range tests {
+		fen, err := chess.FEN(test.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := NewGame(newTestPlayer("black"), newTestPlayer("white"))
+		g.game = chess.NewGame(fen)
+		play(t, g.game, test.bot)
+
+		board, err := chess.FEN(test.board + " w - - 0 1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.board = chess.NewGame(board).Position().Board()
+
+		if early := g.earlyMove(); early != test.early {
+			t.Errorf("%s: expected %q, got %q", test.name, test.early, early)
+		}
+	}
+}
@@ -1,20 +1,60 @@
 package game
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/notnil/chess"
 )
 
+// Game is a state machine driven by a single game loop. All changes of a running game are sent to
+// the loop as events, so its methods can be called concurrently.
 type Game struct {
-	black Player
-	white Player
-	uis   []UI
-	game  *chess.Game
+	id            string
+	black         Player
+	white         Player
+	subscribers   []Subscriber
+	game          *chess.Game
+	clock         *Clock
+	journalFolder string
+	journal       *Journal
+	variant       string
+	evalEngines   []EvalEngine
+
+	handicap      Handicap
+	handicapGiver chess.Color
+
+	status      GameStatus
+	events      chan event
+	done        chan struct{}
+	endOnce     *sync.Once
+	ctx         context.Context
+	cancel      context.CancelFunc
+	cancelMove  context.CancelFunc
+	moveRequest int
+	discard     bool
+
+	takebackLimit int
+	takebacks     int
+	takeback      *TakebackRequest
+	drawOffer     *DrawOffer
+
+	boardEnabled     bool
+	boardTakebacks   BoardTakebackMode
+	board            *chess.Board
+	boardTakebackPly int
+	// sync is the last reported difference between the board and the game
+	sync *BoardSync
+	// execution is the bot move awaiting execution on the board
+	execution *Execution
+
+	substitute func(chess.Color) (Player, error)
+	failures   map[chess.Color]int
 }
 
 type EvalEngine interface {
@@ -36,135 +76,707 @@ const (
 	EVAL_ACC_BLUNDER    EvalAccuracy = "Blunder"
 )
 
+type GameStatus string
+
+const (
+	STATUS_RUNNING  GameStatus = "running"
+	STATUS_PAUSED   GameStatus = "paused"
+	STATUS_ABORTED  GameStatus = "aborted"
+	STATUS_FINISHED GameStatus = "finished"
+)
+
+// MAX_PLAYER_RESTARTS is the number of restarts in a row before a failing player is substituted or the game is adjudicated
+const MAX_PLAYER_RESTARTS = 2
+
 type Player interface {
-	MakeMove(*chess.Game)
+	// MakeMove returns the move of the player without changing the game. It must return once the context is cancelled.
+	MakeMove(context.Context, *chess.Game) (*chess.Move, error)
 	SetColor(chess.Color)
 	Name() string
 	IsBot() bool
 	End()
 }
 
-type UI interface {
+// TimedPlayer is implemented by players that divide their time on the clock themselves, e.g. engines
+type TimedPlayer interface {
+	// MakeTimedMove is MakeMove with the times of the clock
+	MakeTimedMove(context.Context, *chess.Game, SearchTime) (*chess.Move, error)
+}
+
+// TypedPlayer is implemented by players whose type is saved with the game, so the player can be restored when the
+// game is resumed, e.g. whether a human plays on the board or in the browser
+type TypedPlayer interface {
+	Type() string
+}
+
+// PlayerType returns the type of the player of a color saved in a game or an empty string if it was not saved
+func PlayerType(pgn *chess.Game, color chess.Color) string {
+	if tag := pgn.GetTagPair(color.Name() + "Type"); tag != nil {
+		return tag.Value
+	}
+	return ""
+}
+
+// Restartable is implemented by players that can recover from errors, e.g. by restarting their engine
+type Restartable interface {
+	Restart() error
+}
+
+// Subscriber is notified after every event of a game, e.g. to render or persist it
+type Subscriber interface {
 	Render(chess.Game, UIAction)
 }
 
 type UIAction struct {
+	Event      EventType
 	Move       *chess.Move
 	Evaluation *EvalResult
+	Clock      *ClockState
+	Status     GameStatus
+	// Takeback is the pending takeback request
+	Takeback *TakebackRequest
+	// TakebacksLeft is the number of remaining takebacks or TAKEBACKS_UNLIMITED
+	TakebacksLeft int
+	// DrawOffer is the pending draw offer
+	DrawOffer *DrawOffer
+	// Execution is the bot move that still has to be made on the board
+	Execution *Execution
 }
 
-func NewGame(black, white Player, uis ...UI) *Game {
+func NewGame(black, white Player, subscribers ...Subscriber) *Game {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Game{
-		black: black,
-		white: white,
-		uis:   uis,
+		id:          fmt.Sprintf("%d_%s_vs_%s", time.Now().UnixMilli(), white.Name(), black.Name()),
+		black:       black,
+		white:       white,
+		subscribers: subscribers,
+		events:      make(chan event),
+		done:        make(chan struct{}),
+		endOnce:     &sync.Once{},
+		ctx:         ctx,
+		cancel:      cancel,
+		failures:    map[chess.Color]int{},
+
+		takebackLimit:    TAKEBACKS_UNLIMITED,
+		boardTakebackPly: -1,
 	}
 }
 
-func (g *Game) Start(fenString string, evalEngines ...EvalEngine) {
-	// TODO check castling availability
-	// fen, err := chess.FEN(fmt.Sprintf("%s w KQkq - 0 1", fenString))
-	// if err != nil {
-	// 	panic(err)
-	// }
-	g.game = chess.NewGame()
+func (g *Game) ID() string {
+	return g.id
+}
+
+// Subscribe adds a subscriber for the events of the game. It has to be called before the game is started.
+func (g *Game) Subscribe(subscriber Subscriber) {
+	g.subscribers = append(g.subscribers, subscriber)
+}
+
+// EnableJournal writes the game to a journal in the given folder after every change
+func (g *Game) EnableJournal(folder string) {
+	g.journalFolder = folder
+}
+
+func (g *Game) SetVariant(variant string) {
+	g.variant = variant
+}
+
+func (g *Game) SetHandicap(handicap Handicap, giver chess.Color) {
+	g.handicap = handicap
+	g.handicapGiver = giver
+}
+
+func (g *Game) SetTimeControl(tc TimeControl) {
+	g.clock = NewClock(tc)
+}
+
+// SetSubstitute sets a factory for a bot that takes over if a bot keeps failing to move
+func (g *Game) SetSubstitute(substitute func(chess.Color) (Player, error)) {
+	g.substitute = substitute
+}
+
+// Start plays a game from the given FEN. The standard starting position is used if the FEN is empty.
+func (g *Game) Start(fenString string, evalEngines ...EvalEngine) error {
+	if fenString == "" {
+		fenString = startFEN
+	}
+
+	fen, err := chess.FEN(fenString)
+	if err != nil {
+		g.end()
+		return err
+	}
+	g.game = chess.NewGame(fen)
+
+	if len(g.game.ValidMoves()) == 0 {
+		g.end()
+		return fmt.Errorf("no legal moves in position %s", fenString)
+	}
 
 	g.game.AddTagPair("White", g.white.Name())
 	g.game.AddTagPair("Black", g.black.Name())
+	for _, color := range []chess.Color{chess.White, chess.Black} {
+		if typed, ok := g.player(color).(TypedPlayer); ok {
+			g.game.AddTagPair(color.Name()+"Type", typed.Type())
+		}
+	}
 	g.game.AddTagPair("Date", time.Now().Format("02/01/2006 15:04:05"))
+	if g.variant != "" {
+		g.game.AddTagPair("Variant", g.variant)
+	}
+	if g.game.FEN() != startFEN || g.variant != "" {
+		g.game.AddTagPair("SetUp", "1")
+	}
 	g.game.AddTagPair("FEN", g.game.FEN())
+	if g.handicap != "" {
+		g.game.AddTagPair("Handicap", string(g.handicap))
+		g.game.AddTagPair("HandicapGiver", g.handicapGiver.Name())
+	}
+
+	if g.clock != nil {
+		g.game.AddTagPair("TimeControl", g.clock.control.String())
+		if g.clock.control.Mode == CLOCK_MODE_DELAY {
+			g.game.AddTagPair("ClockMode", string(CLOCK_MODE_DELAY))
+		}
+	}
 
 	if g.white.IsBot() || g.black.IsBot() {
 		g.game.AddTagPair("Botgame", "true")
 	}
 
+	if g.takebackLimit != TAKEBACKS_UNLIMITED {
+		g.game.AddTagPair("TakebackLimit", strconv.Itoa(g.takebackLimit))
+	}
+
+	g.run(evalEngines)
+
+	return nil
+}
+
+// Resume continues an unfinished game with the given id
+func (g *Game) Resume(id string, pgn *chess.Game, evalEngines ...EvalEngine) error {
+	if pgn.Outcome() != chess.NoOutcome {
+		g.end()
+		return fmt.Errorf("game %s is already finished", id)
+	}
+
+	fenString := startFEN
+	if tag := pgn.GetTagPair("FEN"); tag != nil {
+		fenString = tag.Value
+	}
+
+	// replay all moves since games decoded from PGN ignore automatic draws
+	game, err := replay(fenString, pgn.TagPairs(), pgn.Moves(), pgn.Comments())
+	if err != nil {
+		g.end()
+		return err
+	}
+	g.game = game
+
+	if tag := pgn.GetTagPair("TimeControl"); tag != nil {
+		mode := CLOCK_MODE_INCREMENT
+		if modeTag := pgn.GetTagPair("ClockMode"); modeTag != nil {
+			mode = ClockMode(modeTag.Value)
+		}
+
+		tc, err := ParseTimeControl(tag.Value, mode)
+		if err != nil {
+			g.end()
+			return err
+		}
+		g.clock = NewClock(*tc)
+		g.restoreClock()
+	}
+	g.restoreTakebacks()
+
+	if tag := g.game.GetTagPair("Termination"); tag != nil && tag.Value == "unterminated" {
+		g.game.RemoveTagPair("Termination")
+	}
+	g.game.RemoveTagPair("Result")
+
+	g.id = id
+	log.Printf("Resuming game %s after %d moves", id, len(g.game.Moves()))
+
+	g.run(evalEngines)
+
+	return nil
+}
+
+// replay creates a game from the start position and plays the moves with their comments
+func replay(fenString string, tags []*chess.TagPair, moves []*chess.Move, comments [][]string) (*chess.Game, error) {
+	fen, err := chess.FEN(fenString)
+	if err != nil {
+		return nil, err
+	}
+
+	game := chess.NewGame(fen, chess.TagPairs(tags))
+	for i, move := range moves {
+		if err := game.Move(move); err != nil {
+			return nil, err
+		}
+		if i < len(comments) {
+			for _, comment := range comments[i] {
+				game.AddComment(game.Moves()[i], comment)
+			}
+		}
+	}
+
+	return game, nil
+}
+
+// restoreClock sets the remaining times from the last [%clk] comments of each color
+func (g *Game) restoreClock() {
+	positions := g.game.Positions()
+	comments := g.game.Comments()
+
+	moves := map[chess.Color]int{}
+	for i := range g.game.Moves() {
+		color := positions[i].Turn()
+		moves[color]++
+
+		if i >= len(comments) {
+			continue
+		}
+		for _, comment := range comments[i] {
+			if remaining, ok := ParseClockComment(comment); ok {
+				g.clock.Set(color, remaining, moves[color])
+			}
+		}
+	}
+}
+
+func (g *Game) run(evalEngines []EvalEngine) {
+	g.evalEngines = evalEngines
 	g.black.SetColor(chess.Black)
 	g.white.SetColor(chess.White)
 
-	g.callUIs(UIAction{})
+	if g.journalFolder != "" {
+		g.journal = NewJournal(g.journalFolder, g.id)
+		g.Subscribe(g.journal)
+	}
 
-	wg := &sync.WaitGroup{}
-	go func() {
-		for g.game.Outcome() == chess.NoOutcome {
-			if g.game.Position().Turn() == chess.Black {
-				g.black.MakeMove(g.game)
-			} else {
-				g.white.MakeMove(g.game)
+	g.status = STATUS_RUNNING
+	g.notify(UIAction{Event: EVENT_STARTED})
+
+	for g.status == STATUS_RUNNING || g.status == STATUS_PAUSED {
+		g.checkSync()
+		g.checkExecution()
+		if g.status == STATUS_RUNNING && g.cancelMove == nil && !g.awaitingBoard() {
+			g.requestMove()
+		}
+
+		var flag <-chan time.Time
+		var timer *time.Timer
+		if g.clock != nil {
+			if state := g.clock.State(); state.Running != chess.NoColor {
+				timer = time.NewTimer(g.clock.Remaining(state.Running))
+				flag = timer.C
+			}
+		}
+
+		select {
+		case e := <-g.events:
+			g.handle(e)
+		case <-flag:
+			if flagged := g.clock.Flagged(); flagged != chess.NoColor {
+				g.timeout(flagged)
 			}
+		}
 
-			move := g.game.Moves()[len(g.game.Moves())-1]
-			g.callEvalEngines(evalEngines)
-			g.callUIs(UIAction{
-				Move: move,
-			})
+		if timer != nil {
+			timer.Stop()
 		}
-	}()
+
+		if g.game.Outcome() != chess.NoOutcome {
+			g.status = STATUS_FINISHED
+		}
+	}
+
+	g.cancelMoveRequest()
+	g.execution = nil
+	if g.clock != nil {
+		g.clock.Stop()
+	}
+
+	if g.status == STATUS_ABORTED {
+		g.game.AddTagPair("Termination", "unterminated")
+		log.Printf("Game %s aborted", g.id)
+	}
+
+	g.game.AddTagPair("Result", g.game.Outcome().String())
+	g.notify(UIAction{Event: EVENT_ENDED})
+
+	g.cancel()
+	g.end()
+}
+
+// end stops accepting events and ends the players
+func (g *Game) end() {
+	g.endOnce.Do(func() {
+		close(g.done)
+	})
+	g.endPlayers()
+}
+
+// Done is closed once the game has ended
+func (g *Game) Done() <-chan struct{} {
+	return g.done
+}
+
+// requestMove asks the player to move in the background. The move is handed to the game loop as event.
+func (g *Game) requestMove() {
+	turn := g.game.Position().Turn()
+	if g.clock != nil {
+		g.clock.Start(turn)
+	}
+
+	ctx, cancel := context.WithCancel(g.ctx)
+	g.cancelMove = cancel
+	g.moveRequest++
+
+	request := g.moveRequest
+	player := g.player(turn)
+	// the clone shares the positions, which cache their valid moves on first use. They are computed before the player
+	// reads them concurrently to the game loop.
+	g.game.Position().ValidMoves()
+	position := g.game.Clone()
+	var searchTime *SearchTime
+	if g.clock != nil {
+		t := g.clock.SearchTime()
+		searchTime = &t
+	}
 	go func() {
-		for g.game.Outcome() == chess.NoOutcome {
-			time.Sleep(500 * time.Millisecond)
+		var move *chess.Move
+		var err error
+		if timed, ok := player.(TimedPlayer); ok && searchTime != nil {
+			move, err = timed.MakeTimedMove(ctx, position, *searchTime)
+		} else {
+			move, err = player.MakeMove(ctx, position)
 		}
-		wg.Done()
+		if ctx.Err() != nil {
+			// the move was interrupted, e.g. by a pause, an undo or an abort
+			return
+		}
+
+		g.post(event{
+			eventType: EVENT_MOVE,
+			request:   request,
+			move:      move,
+			err:       err,
+		})
 	}()
-	wg.Add(1)
-	wg.Wait()
+}
 
-	g.game.AddTagPair("Result", g.game.Outcome().String())
-	g.callUIs(UIAction{})
+func (g *Game) cancelMoveRequest() {
+	if g.cancelMove != nil {
+		g.cancelMove()
+		g.cancelMove = nil
+	}
+}
+
+func (g *Game) handleMove(e event) {
+	if e.request != g.moveRequest || g.cancelMove == nil {
+		return
+	}
+	g.cancelMoveRequest()
+
+	turn := g.game.Position().Turn()
+	err := e.err
+	if err == nil {
+		err = g.game.Move(e.move)
+	}
+	if err != nil {
+		g.recoverPlayer(turn, err)
+		return
+	}
+	g.failures[turn] = 0
+	g.withdrawTakeback()
+	g.withdrawDrawOffer()
+
+	move := g.lastMove()
+	if g.clock != nil {
+		if remaining := g.clock.Press(); remaining > 0 {
+			g.game.AddComment(move, fmt.Sprintf("[%%clk %s]", FormatClock(remaining)))
+		} else {
+			g.timeout(turn)
+		}
+	}
+
+	if g.game.Outcome() == chess.Draw {
+		g.game.AddComment(move, fmt.Sprintf("Draw by %s", DrawMethodName(g.game.Method())))
+		log.Printf("Game drawn by %s", DrawMethodName(g.game.Method()))
+	}
 
-	g.black.End()
-	g.white.End()
+	g.expectExecution(turn, move)
+	g.callEvalEngines()
+	g.notify(UIAction{
+		Event: EVENT_MOVE,
+		Move:  move,
+	})
+}
+
+// Pause stops the clock and interrupts the player to move until Continue is called
+func (g *Game) Pause() error {
+	return g.send(event{eventType: EVENT_PAUSE})
+}
+
+func (g *Game) pause() error {
+	if g.status != STATUS_RUNNING {
+		return ErrGameNotRunning
+	}
+
+	g.status = STATUS_PAUSED
+	if g.clock != nil {
+		g.clock.Stop()
+	}
+	g.cancelMoveRequest()
+
+	log.Printf("Game %s paused", g.id)
+	g.notify(UIAction{Event: EVENT_PAUSE})
+	return nil
+}
+
+func (g *Game) Continue() error {
+	return g.send(event{eventType: EVENT_CONTINUE})
+}
+
+func (g *Game) unpause() error {
+	if g.status != STATUS_PAUSED {
+		return errors.New("game is not paused")
+	}
+
+	g.status = STATUS_RUNNING
+
+	log.Printf("Game %s continued", g.id)
+	g.notify(UIAction{Event: EVENT_CONTINUE})
+	return nil
 }
 
-func (g *Game) callUIs(action UIAction) {
-	for _, ui := range g.uis {
-		ui.Render(*g.game, action)
+// Abort stops a running game. An aborted game is saved unfinished unless it should be discarded.
+func (g *Game) Abort(discard bool) error {
+	return g.send(event{eventType: EVENT_ABORT, discard: discard})
+}
+
+func (g *Game) abort(discard bool) {
+	g.status = STATUS_ABORTED
+	g.discard = discard
+	g.cancelMoveRequest()
+
+	g.notify(UIAction{Event: EVENT_ABORT})
+}
+
+func (g *Game) player(color chess.Color) Player {
+	if color == chess.Black {
+		return g.black
+	}
+	return g.white
+}
+
+func (g *Game) setPlayer(color chess.Color, p Player) {
+	if color == chess.Black {
+		g.black = p
+	} else {
+		g.white = p
+	}
+}
+
+// recoverPlayer keeps the game going after a player failed to move by restarting or substituting the player.
+// The game is adjudicated if neither is possible.
+func (g *Game) recoverPlayer(color chess.Color, err error) {
+	p := g.player(color)
+	log.Printf("%s failed to move: %v", p.Name(), err)
+
+	g.failures[color]++
+	if restartable, ok := p.(Restartable); ok && g.failures[color] <= MAX_PLAYER_RESTARTS {
+		err := restartable.Restart()
+		if err == nil {
+			return
+		}
+		log.Printf("error restarting %s: %v", p.Name(), err)
+	}
+
+	if g.substitute != nil && p.IsBot() {
+		substitute, err := g.substitute(color)
+		if err == nil {
+			substitute.SetColor(color)
+			g.setPlayer(color, substitute)
+			p.End()
+
+			g.failures[color] = 0
+			g.substitute = nil
+			if move := g.lastMove(); move != nil {
+				g.game.AddComment(move, fmt.Sprintf("%s replaced by %s", p.Name(), substitute.Name()))
+			}
+			log.Printf("%s replaced by %s", p.Name(), substitute.Name())
+			g.notify(UIAction{Event: EVENT_PLAYER_SUBSTITUTED})
+			return
+		}
+		log.Printf("error creating substitute for %s: %v", p.Name(), err)
+	}
+
+	g.forfeit(color, "adjudication")
+	log.Printf("Game adjudicated after %s failed to move", p.Name())
+}
+
+func (g *Game) endPlayers() {
+	g.player(chess.Black).End()
+	g.player(chess.White).End()
+}
+
+// notify passes the game to all subscribers. It must only be called by the game loop.
+func (g *Game) notify(action UIAction) {
+	if g.clock != nil {
+		state := g.clock.State()
+		action.Clock = &state
+	}
+	action.Status = g.status
+	action.Takeback = g.takeback
+	action.TakebacksLeft = g.takebacksLeft()
+	action.DrawOffer = g.drawOffer
+	action.Execution = g.execution
+
+	for _, subscriber := range g.subscribers {
+		subscriber.Render(*g.game, action)
 	}
 
 	log.Println("UI update send", action)
 }
 
-func (g *Game) callEvalEngines(engines []EvalEngine) {
-	for _, engine := range engines {
+func (g *Game) callEvalEngines() {
+	for _, engine := range g.evalEngines {
+		log.Println("Calling eval engine")
+		evaluation := engine.Eval(g.game)
+		log.Println("Eval engine called")
 
-		func(engine EvalEngine, game *chess.Game) {
-			log.Println("Calling eval engine")
-			evaluation := engine.Eval(game)
-			log.Println("Eval engine called")
+		g.notify(UIAction{
+			Event:      EVENT_EVALUATION,
+			Evaluation: &evaluation,
+		})
+	}
+}
 
-			g.callUIs(UIAction{
-				Evaluation: &evaluation,
-			})
-		}(engine, g.game)
+// timeout ends the game after the flag of the given color has fallen
+func (g *Game) timeout(color chess.Color) {
+	if g.forfeit(color, "time forfeit") {
+		log.Printf("%s lost on time", color.Name())
+		g.notify(UIAction{Event: EVENT_TIMEOUT})
 	}
 }
 
+// forfeit ends the game as lost for the given color or as draw if the opponent can not checkmate anymore
+func (g *Game) forfeit(color chess.Color, termination string) bool {
+	if g.game.Outcome() != chess.NoOutcome {
+		return false
+	}
+
+	if hasMatingMaterial(g.game.Position().Board(), color.Other()) {
+		g.game.Resign(color)
+	} else {
+		g.game.Draw(chess.DrawOffer)
+	}
+	g.game.AddTagPair("Termination", termination)
+	return true
+}
+
 func (g *Game) UndoMoves(n int) error {
-	err := g.game.UndoMoves(n)
-	move := g.game.Moves()[len(g.game.Moves())-1]
-	g.callUIs(UIAction{
-		Move: move,
+	return g.send(event{eventType: EVENT_UNDO, n: n})
+}
+
+func (g *Game) undoMoves(n int) error {
+	if err := g.undo(n); err != nil {
+		return err
+	}
+	g.withdrawTakeback()
+	g.withdrawDrawOffer()
+
+	g.notify(UIAction{
+		Event: EVENT_UNDO,
+		Move:  g.lastMove(),
 	})
+	return nil
+}
+
+// draw ends the game by an accepted draw offer
+func (g *Game) draw() error {
+	if err := g.game.Draw(chess.DrawOffer); err != nil {
+		return err
+	}
+
+	g.notify(UIAction{
+		Event: EVENT_DRAW,
+		Move:  g.lastMove(),
+	})
+	return nil
+}
 
-	return err
+// ClaimDraw ends the game by threefold repetition or the fifty-move rule if the claim is valid
+func (g *Game) ClaimDraw(method chess.Method) error {
+	return g.send(event{eventType: EVENT_CLAIM_DRAW, method: method})
 }
 
-func (g *Game) Draw() {
-	g.game.Draw(chess.DrawOffer)
-	move := g.game.Moves()[len(g.game.Moves())-1]
-	g.callUIs(UIAction{
-		Move: move,
+func (g *Game) claimDraw(method chess.Method) error {
+	if method != chess.ThreefoldRepetition && method != chess.FiftyMoveRule {
+		return fmt.Errorf("draw by %s can not be claimed", method)
+	}
+
+	if err := g.game.Draw(method); err != nil {
+		return err
+	}
+
+	move := g.lastMove()
+	if move != nil {
+		g.game.AddComment(move, fmt.Sprintf("Draw claimed by %s", DrawMethodName(method)))
+	}
+	log.Printf("Draw claimed by %s", DrawMethodName(method))
+
+	g.notify(UIAction{
+		Event: EVENT_CLAIM_DRAW,
+		Move:  move,
 	})
+	return nil
 }
 
-func (g *Game) Resign() {
+// Resign resigns the game for the side to move
+func (g *Game) Resign() error {
+	return g.send(event{eventType: EVENT_RESIGN})
+}
+
+func (g *Game) resign() error {
 	g.game.Resign(g.game.Position().Turn())
-	move := g.game.Moves()[len(g.game.Moves())-1]
-	g.callUIs(UIAction{
-		Move: move,
+	g.notify(UIAction{
+		Event: EVENT_RESIGN,
+		Move:  g.lastMove(),
 	})
+	return nil
+}
+
+func (g *Game) lastMove() *chess.Move {
+	moves := g.game.Moves()
+	if len(moves) == 0 {
+		return nil
+	}
+	return moves[len(moves)-1]
 }
 
 func (g *Game) Save(folder string) {
-	file := fmt.Sprintf("%s%d_%s_vs_%s.pgn", folder, time.Now().UnixMilli(), g.game.GetTagPair("White").Value, g.game.GetTagPair("Black").Value)
-	ioutil.WriteFile(file, []byte(g.game.String()), 0644)
+	if g.discard {
+		log.Printf("Game %s discarded", g.id)
+		if g.journal != nil {
+			if err := g.journal.Remove(); err != nil {
+				log.Printf("error removing journal: %v", err)
+			}
+		}
+		return
+	}
+
+	file := fmt.Sprintf("%s%s.pgn", folder, g.id)
+	if err := writeFileAtomic(file, []byte(g.game.String())); err != nil {
+		log.Printf("error saving game: %v", err)
+		return
+	}
+
+	if g.journal != nil {
+		if err := g.journal.Remove(); err != nil {
+			log.Printf("error removing journal: %v", err)
+		}
+	}
 }
@@ -0,0 +1,153 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// testPlayer makes the moves sent to it
+type testPlayer struct {
+	name  string
+	moves chan string
+	ended chan struct{}
+}
+
+func newTestPlayer(name string) *testPlayer {
+	return &testPlayer{
+		name:  name,
+		moves: make(chan string, 16),
+		ended: make(chan struct{}),
+	}
+}
+
+func (p *testPlayer) MakeMove(ctx context.Context, game *chess.Game) (*chess.Move, error) {
+	select {
+	case move := <-p.moves:
+		return chess.AlgebraicNotation{}.Decode(game.Position(), move)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *testPlayer) SetColor(chess.Color) {}
+
+func (p *testPlayer) Name() string {
+	return p.name
+}
+
+func (p *testPlayer) IsBot() bool {
+	return false
+}
+
+func (p *testPlayer) End() {
+	select {
+	case <-p.ended:
+	default:
+		close(p.ended)
+	}
+}
+
+// pgnOf parses a PGN or fails the test
+func pgnOf(t *testing.T, pgn string) *chess.Game {
+	t.Helper()
+	option, err := chess.PGN(strings.NewReader(pgn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chess.NewGame(option)
+}
+
+// assertEnded fails if the game or its players were not ended
+func assertEnded(t *testing.T, g *Game, players ...*testPlayer) {
+	t.Helper()
+	select {
+	case <-g.Done():
+	case <-time.After(time.Second):
+		t.Error("expected the game to be done")
+	}
+	for _, p := range players {
+		select {
+		case <-p.ended:
+		case <-time.After(time.Second):
+			t.Errorf("expected player %s to be ended", p.name)
+		}
+	}
+}
+
+func TestResumeFailures(t *testing.T) {
+	// the moves do not fit the start position
+	illegal := pgnOf(t, "1. e4 *")
+	illegal.AddTagPair("FEN", "4k3/8/8/8/8/8/8/4K3 w - - 0 1")
+
+	tests := []struct {
+		name string
+		pgn  *chess.Game
+	}{
+		{name: "finished game", pgn: pgnOf(t, "1. e4 e5 1-0")},
+		{name: "illegal move", pgn: illegal},
+		{name: "invalid time control", pgn: pgnOf(t, "[TimeControl \"abc\"]\n\n1. e4 *")},
+	}
+
+	for _, test := range tests {
+		white, black := newTestPlayer("white"), newTestPlayer("black")
+		g := NewGame(black, white)
+
+		if err := g.Resume("test", test.pgn); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+		assertEnded(t, g, white, black)
+	}
+}
+
+// typedPlayer is a test player whose type is saved with the game
+type typedPlayer struct {
+	*testPlayer
+	playerType string
+}
+
+func (p typedPlayer) Type() string {
+	return p.playerType
+}
+
+// games receives the games rendered by the game
+type games chan chess.Game
+
+func (c games) Render(g chess.Game, action UIAction) {
+	select {
+	case c <- g:
+	default:
+	}
+}
+
+func TestPlayerTypeSaved(t *testing.T) {
+	white, black := newTestPlayer("white"), newTestPlayer("black")
+	rendered := make(games, 16)
+	g := NewGame(black, typedPlayer{white, "board"}, rendered)
+	go g.Start("")
+	white.moves <- "e4"
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case played := <-rendered:
+			if len(played.Moves()) == 0 {
+				continue
+			}
+			if PlayerType(&played, chess.White) != "board" || PlayerType(&played, chess.Black) != "" {
+				t.Errorf("expected only the type of white to be saved, got %v", played.TagPairs())
+			}
+		case <-timeout:
+			t.Fatal("expected the move to be rendered")
+		}
+		break
+	}
+
+	if err := g.Abort(true); err != nil {
+		t.Fatal(err)
+	}
+	assertEnded(t, g, white, black)
+}
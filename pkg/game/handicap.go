@@ -0,0 +1,52 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/notnil/chess"
+)
+
+type Handicap string
+
+const (
+	HANDICAP_PAWN          Handicap = "pawn"
+	HANDICAP_PAWN_AND_MOVE Handicap = "pawn-and-move"
+	HANDICAP_KNIGHT        Handicap = "knight"
+	HANDICAP_ROOK          Handicap = "rook"
+	HANDICAP_QUEEN         Handicap = "queen"
+)
+
+// handicapSquares contains the squares of the removed pieces from white's point of view
+var handicapSquares = map[Handicap][]chess.Square{
+	HANDICAP_PAWN:          {chess.F2},
+	HANDICAP_PAWN_AND_MOVE: {chess.F2},
+	HANDICAP_KNIGHT:        {chess.B1},
+	HANDICAP_ROOK:          {chess.A1},
+	HANDICAP_QUEEN:         {chess.D1},
+}
+
+// HandicapFEN returns the start position after the given color removed the pieces of the handicap
+func HandicapFEN(handicap Handicap, giver chess.Color) (string, error) {
+	squares, ok := handicapSquares[handicap]
+	if !ok {
+		return "", fmt.Errorf("unknown handicap %s", handicap)
+	}
+	if giver == chess.NoColor {
+		return "", fmt.Errorf("no color gives the %s handicap", handicap)
+	}
+
+	board := chess.StartingPosition().Board().SquareMap()
+	for _, sq := range squares {
+		if giver == chess.Black {
+			sq = chess.NewSquare(sq.File(), chess.Rank(7-sq.Rank()))
+		}
+		delete(board, sq)
+	}
+
+	turn := chess.White
+	if handicap == HANDICAP_PAWN_AND_MOVE {
+		turn = giver.Other()
+	}
+
+	return FENFromBoard(*chess.NewBoard(board), turn)
+}
@@ -0,0 +1,44 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+func TestHandicapFEN(t *testing.T) {
+	tests := []struct {
+		handicap Handicap
+		giver    chess.Color
+		fen      string
+	}{
+		{HANDICAP_PAWN, chess.White, "rnbqkbnr/pppppppp/8/8/8/8/PPPPP1PP/RNBQKBNR w KQkq - 0 1"},
+		{HANDICAP_PAWN, chess.Black, "rnbqkbnr/ppppp1pp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+		{HANDICAP_PAWN_AND_MOVE, chess.White, "rnbqkbnr/pppppppp/8/8/8/8/PPPPP1PP/RNBQKBNR b KQkq - 0 1"},
+		{HANDICAP_PAWN_AND_MOVE, chess.Black, "rnbqkbnr/ppppp1pp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+		{HANDICAP_KNIGHT, chess.White, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/R1BQKBNR w KQkq - 0 1"},
+		{HANDICAP_KNIGHT, chess.Black, "r1bqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+		{HANDICAP_ROOK, chess.White, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/1NBQKBNR w Kkq - 0 1"},
+		{HANDICAP_ROOK, chess.Black, "1nbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQk - 0 1"},
+		{HANDICAP_QUEEN, chess.White, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNB1KBNR w KQkq - 0 1"},
+		{HANDICAP_QUEEN, chess.Black, "rnb1kbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+	}
+
+	for _, test := range tests {
+		fen, err := HandicapFEN(test.handicap, test.giver)
+		if err != nil {
+			t.Errorf("%s by %s: unexpected error %v", test.handicap, test.giver.Name(), err)
+			continue
+		}
+		if fen != test.fen {
+			t.Errorf("%s by %s: expected %s, got %s", test.handicap, test.giver.Name(), test.fen, fen)
+		}
+	}
+
+	if _, err := HandicapFEN("bishop", chess.White); err == nil {
+		t.Error("expected an error for an unknown handicap")
+	}
+	if _, err := HandicapFEN(HANDICAP_QUEEN, chess.NoColor); err == nil {
+		t.Error("expected an error without a giver")
+	}
+}
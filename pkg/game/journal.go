@@ -0,0 +1,93 @@
+package game
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+const JOURNAL_SUFFIX = ".journal"
+
+// Journal keeps the PGN of a running game on disk so it can be recovered after a crash.
+// It subscribes to the game and writes the journal after every event.
+type Journal struct {
+	folder string
+	id     string
+}
+
+func NewJournal(folder, id string) *Journal {
+	return &Journal{
+		folder: folder,
+		id:     id,
+	}
+}
+
+func (j *Journal) file() string {
+	return fmt.Sprintf("%s%s%s", j.folder, j.id, JOURNAL_SUFFIX)
+}
+
+func (j *Journal) Render(g chess.Game, action UIAction) {
+	if err := j.Write(&g); err != nil {
+		log.Printf("error writing journal: %v", err)
+	}
+}
+
+func (j *Journal) Write(g *chess.Game) error {
+	return writeFileAtomic(j.file(), []byte(g.String()))
+}
+
+func (j *Journal) Remove() error {
+	err := os.Remove(j.file())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// FindJournals returns the file names of all journals in a folder
+func FindJournals(folder string) ([]string, error) {
+	files, err := ioutil.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	journals := []string{}
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), JOURNAL_SUFFIX) {
+			journals = append(journals, file.Name())
+		}
+	}
+
+	return journals, nil
+}
+
+// writeFileAtomic writes to a temporary file first and renames it afterwards so the file is never half written
+func writeFileAtomic(file string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,76 @@
+package game
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+func TestJournalRoundTrip(t *testing.T) {
+	folder, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	folder = folder + string(filepath.Separator)
+
+	g := chess.NewGame()
+	g.AddTagPair("White", "Alice")
+	g.AddTagPair("TimeControl", "300+2")
+	for _, move := range []string{"e4", "e5", "Nf3"} {
+		if err := g.MoveStr(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g.AddComment(g.Moves()[0], "[%clk 0:05:01]")
+	g.AddComment(g.Moves()[2], "[%clk 0:04:58]")
+
+	journal := NewJournal(folder, "game1")
+	if err := journal.Write(g); err != nil {
+		t.Fatal(err)
+	}
+
+	journals, err := FindJournals(folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(journals, []string{"game1" + JOURNAL_SUFFIX}) {
+		t.Fatalf("expected the journal to be found, got %v", journals)
+	}
+
+	f, err := os.Open(folder + journals[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	pgn, err := chess.PGN(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := chess.NewGame(pgn)
+
+	if restored.Position().String() != g.Position().String() {
+		t.Errorf("expected position %s, got %s", g.Position(), restored.Position())
+	}
+	if restored.GetTagPair("TimeControl").Value != "300+2" {
+		t.Errorf("expected the time control tag to be restored")
+	}
+	comments := restored.Comments()
+	if len(comments) < 3 || !reflect.DeepEqual(comments[0], []string{"[%clk 0:05:01]"}) || !reflect.DeepEqual(comments[2], []string{"[%clk 0:04:58]"}) {
+		t.Errorf("expected the clock comments to be restored, got %v", comments)
+	}
+
+	if err := journal.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if journals, _ := FindJournals(folder); len(journals) != 0 {
+		t.Errorf("expected the journal to be removed, got %v", journals)
+	}
+	if err := journal.Remove(); err != nil {
+		t.Errorf("removing a missing journal must not fail: %v", err)
+	}
+}
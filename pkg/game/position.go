@@ -0,0 +1,67 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/notnil/chess"
+)
+
+const startFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// FENFromBoard creates a FEN for a position set up on a board. Castling rights are
+// inferred from the placement of kings and rooks.
+func FENFromBoard(board chess.Board, turn chess.Color) (string, error) {
+	if err := validateBoard(board); err != nil {
+		return "", err
+	}
+
+	if turn == chess.NoColor {
+		turn = chess.White
+	}
+
+	return fmt.Sprintf("%s %s %s - 0 1", board.String(), turn.String(), castleRights(board)), nil
+}
+
+func validateBoard(board chess.Board) error {
+	kings := map[chess.Color]int{}
+	for sq, piece := range board.SquareMap() {
+		if piece.Type() == chess.King {
+			kings[piece.Color()]++
+		}
+		if piece.Type() == chess.Pawn && (sq.Rank() == chess.Rank1 || sq.Rank() == chess.Rank8) {
+			return fmt.Errorf("pawn on %s is not allowed", sq.String())
+		}
+	}
+
+	if kings[chess.White] != 1 || kings[chess.Black] != 1 {
+		return errors.New("each side needs exactly one king")
+	}
+
+	return nil
+}
+
+func castleRights(board chess.Board) string {
+	rights := ""
+	if board.Piece(chess.E1) == chess.WhiteKing {
+		if board.Piece(chess.H1) == chess.WhiteRook {
+			rights += "K"
+		}
+		if board.Piece(chess.A1) == chess.WhiteRook {
+			rights += "Q"
+		}
+	}
+	if board.Piece(chess.E8) == chess.BlackKing {
+		if board.Piece(chess.H8) == chess.BlackRook {
+			rights += "k"
+		}
+		if board.Piece(chess.A8) == chess.BlackRook {
+			rights += "q"
+		}
+	}
+
+	if rights == "" {
+		return "-"
+	}
+	return rights
+}
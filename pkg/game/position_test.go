@@ -0,0 +1,43 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+func TestCastleRights(t *testing.T) {
+	tests := []struct {
+		name   string
+		board  string
+		rights string
+	}{
+		{name: "start position", board: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR", rights: "KQkq"},
+		{name: "kings and rooks only", board: "r3k2r/8/8/8/8/8/8/R3K2R", rights: "KQkq"},
+		{name: "white king moved", board: "r3k2r/8/8/8/8/8/8/R2K3R", rights: "kq"},
+		{name: "black king moved", board: "r4k1r/8/8/8/8/8/8/R3K2R", rights: "KQ"},
+		{name: "kingside rooks missing", board: "r3k3/8/8/8/8/8/8/R3K3", rights: "Qq"},
+		{name: "queenside rooks missing", board: "4k2r/8/8/8/8/8/8/4K2R", rights: "Kk"},
+		{name: "rooks moved", board: "1r2k1r1/8/8/8/8/8/8/1R2K1R1", rights: "-"},
+		{name: "rooks of the other color", board: "R3k2R/8/8/8/8/8/8/r3K2r", rights: "-"},
+		{name: "no rooks", board: "4k3/8/8/8/8/8/8/4K3", rights: "-"},
+	}
+
+	for _, test := range tests {
+		fen, err := chess.FEN(test.board + " w - - 0 1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		board := *chess.NewGame(fen).Position().Board()
+
+		actual, err := FENFromBoard(board, chess.White)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		expected := test.board + " w " + test.rights + " - 0 1"
+		if actual != expected {
+			t.Errorf("%s: expected %s, got %s", test.name, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+// TAKEBACK_COMMENT_PREFIX starts the PGN comment added where moves were taken back
+const TAKEBACK_COMMENT_PREFIX = "Takeback by"
+
+// TAKEBACKS_TAG is the PGN tag holding the number of takebacks, so it survives taking back all moves
+const TAKEBACKS_TAG = "Takebacks"
+
+// TAKEBACKS_UNLIMITED allows any number of takebacks per game
+const TAKEBACKS_UNLIMITED = -1
+
+var ErrTakebackDeclined = errors.New("takeback declined")
+
+// TakebackRequest is a pending request of a player to take back its last move
+type TakebackRequest struct {
+	By    chess.Color
+	Moves int
+}
+
+// TakebackPolicy is implemented by bots deciding themselves whether to accept a takeback
+type TakebackPolicy interface {
+	AcceptTakeback(game *chess.Game, by chess.Color) bool
+}
+
+// SetTakebackLimit sets the number of takebacks allowed per game
+func (g *Game) SetTakebackLimit(limit int) {
+	g.takebackLimit = limit
+}
+
+// RequestTakeback asks the opponent to take back the last move of the given color. If no color is given, a
+// human playing against a bot or the player who made the last move requests the takeback.
+func (g *Game) RequestTakeback(by chess.Color) error {
+	return g.send(event{eventType: EVENT_TAKEBACK_REQUEST, color: by})
+}
+
+// AnswerTakeback accepts or declines the pending takeback request
+func (g *Game) AnswerTakeback(accept bool) error {
+	eventType := EVENT_TAKEBACK_DECLINE
+	if accept {
+		eventType = EVENT_TAKEBACK_ACCEPT
+	}
+
+	return g.send(event{eventType: eventType})
+}
+
+// requestTakeback requests to take back n moves. If n is 0, the moves since the last move of the requester are taken back.
+func (g *Game) requestTakeback(by chess.Color, n int) error {
+	if g.takeback != nil {
+		return errors.New("a takeback is already requested")
+	}
+	if g.takebacksLeft() == 0 {
+		return errors.New("no takebacks left")
+	}
+
+	if by == chess.NoColor {
+		by = g.requester()
+	}
+
+	moves := g.game.Moves()
+	if n == 0 {
+		n = 2
+		if len(moves) > 0 && g.game.Positions()[len(moves)-1].Turn() == by {
+			// the requester made the last move
+			n = 1
+		}
+	}
+	if n > len(moves) {
+		return errors.New("no move to take back")
+	}
+
+	request := &TakebackRequest{
+		By:    by,
+		Moves: n,
+	}
+
+	opponent := g.player(by.Other())
+	if policy, ok := opponent.(TakebackPolicy); ok {
+		if !policy.AcceptTakeback(g.game, by) {
+			log.Printf("%s declined the takeback of %s", opponent.Name(), by.Name())
+			return ErrTakebackDeclined
+		}
+		g.takeBack(request)
+		return nil
+	}
+	if opponent.IsBot() {
+		g.takeBack(request)
+		return nil
+	}
+
+	g.takeback = request
+	log.Printf("%s requests a takeback", by.Name())
+	g.notify(UIAction{Event: EVENT_TAKEBACK_REQUEST})
+	return nil
+}
+
+// requester returns the human playing against a bot or the color that made the last move. It requests takebacks and
+// offers draws if no color is given.
+func (g *Game) requester() chess.Color {
+	switch {
+	case g.white.IsBot() && !g.black.IsBot():
+		return chess.Black
+	case g.black.IsBot() && !g.white.IsBot():
+		return chess.White
+	}
+
+	return g.game.Position().Turn().Other()
+}
+
+func (g *Game) answerTakeback(accept bool) error {
+	request := g.takeback
+	if request == nil {
+		return errors.New("no takeback requested")
+	}
+	g.takeback = nil
+
+	if !accept {
+		log.Printf("Takeback of %s declined", request.By.Name())
+		g.notify(UIAction{Event: EVENT_TAKEBACK_DECLINE})
+		return nil
+	}
+
+	g.takeBack(request)
+	return nil
+}
+
+// withdrawTakeback drops a pending request, e.g. after the opponent moved
+func (g *Game) withdrawTakeback() {
+	if g.takeback != nil {
+		log.Printf("Takeback of %s withdrawn", g.takeback.By.Name())
+		g.takeback = nil
+	}
+}
+
+func (g *Game) takeBack(request *TakebackRequest) {
+	moves := g.game.Moves()
+	positions := g.game.Positions()
+
+	taken := []string{}
+	for i := len(moves) - request.Moves; i < len(moves); i++ {
+		taken = append(taken, chess.AlgebraicNotation{}.Encode(positions[i], moves[i]))
+	}
+
+	if err := g.undo(request.Moves); err != nil {
+		log.Printf("error taking back moves: %v", err)
+		return
+	}
+	g.takebacks++
+	g.game.AddTagPair(TAKEBACKS_TAG, strconv.Itoa(g.takebacks))
+
+	if move := g.lastMove(); move != nil {
+		g.game.AddComment(move, fmt.Sprintf("%s %s: %s", TAKEBACK_COMMENT_PREFIX, request.By.Name(), strings.Join(taken, " ")))
+	}
+	log.Printf("%s took back %s", request.By.Name(), strings.Join(taken, " "))
+
+	g.notify(UIAction{
+		Event: EVENT_TAKEBACK_ACCEPT,
+		Move:  g.lastMove(),
+	})
+}
+
+// takebacksLeft returns the number of remaining takebacks or TAKEBACKS_UNLIMITED
+func (g *Game) takebacksLeft() int {
+	if g.takebackLimit == TAKEBACKS_UNLIMITED {
+		return TAKEBACKS_UNLIMITED
+	}
+	if g.takebacks >= g.takebackLimit {
+		return 0
+	}
+	return g.takebackLimit - g.takebacks
+}
+
+// restoreTakebacks restores the takeback limit and the number of takebacks of a resumed game
+func (g *Game) restoreTakebacks() {
+	if tag := g.game.GetTagPair("TakebackLimit"); tag != nil {
+		if limit, err := strconv.Atoi(tag.Value); err == nil {
+			g.takebackLimit = limit
+		}
+	}
+
+	if tag := g.game.GetTagPair(TAKEBACKS_TAG); tag != nil {
+		if takebacks, err := strconv.Atoi(tag.Value); err == nil {
+			g.takebacks = takebacks
+			return
+		}
+	}
+
+	// games saved without the tag
+	for _, comments := range g.game.Comments() {
+		for _, comment := range comments {
+			if strings.HasPrefix(comment, TAKEBACK_COMMENT_PREFIX) {
+				g.takebacks++
+			}
+		}
+	}
+}
+
+// undo takes back the last n moves including their comments and rewinds the number of moves on the clock
+func (g *Game) undo(n int) error {
+	if err := g.game.UndoMoves(n); err != nil {
+		return err
+	}
+	g.cancelMoveRequest()
+	g.execution = nil
+
+	// chess.Game keeps the comments of the moves unless every move has a comment
+	moves := g.game.Moves()
+	if len(g.game.Comments()) > len(moves) {
+		game, err := replay(g.game.Positions()[0].String(), g.game.TagPairs(), moves, g.game.Comments())
+		if err != nil {
+			return err
+		}
+		g.game = game
+	}
+
+	if g.clock != nil {
+		g.clock.Stop()
+		played := map[chess.Color]int{}
+		for _, pos := range g.game.Positions()[:len(moves)] {
+			played[pos.Turn()]++
+		}
+		for _, color := range []chess.Color{chess.White, chess.Black} {
+			g.clock.Set(color, g.clock.Remaining(color), played[color])
+		}
+	}
+
+	return nil
+}
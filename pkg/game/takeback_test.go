@@ -0,0 +1,102 @@
+package game
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// newTakebackGame returns a game with the moves played and a comment on the given moves
+func newTakebackGame(t *testing.T, moves []string, commented ...int) *Game {
+	t.Helper()
+	g := NewGame(newTestPlayer("black"), newTestPlayer("white"))
+	g.game = chess.NewGame()
+	play(t, g.game, moves...)
+	for _, i := range commented {
+		g.game.AddComment(g.game.Moves()[i], "comment "+moves[i])
+	}
+	return g
+}
+
+func TestTakeBackTrimsComments(t *testing.T) {
+	moves := []string{"e4", "e5", "Nf3", "Nc6", "Bb5"}
+	// the library trims the comments only if every move has one
+	g := newTakebackGame(t, moves, 0, 3)
+
+	g.takeBack(&TakebackRequest{By: chess.White, Moves: 3})
+	if len(g.game.Moves()) != 2 {
+		t.Fatalf("expected 2 moves left, got %d", len(g.game.Moves()))
+	}
+
+	play(t, g.game, "Nf3", "Nc6")
+	for i, comments := range g.game.Comments() {
+		for _, comment := range comments {
+			if comment == "comment Nc6" {
+				t.Errorf("the comment of the taken back move is still attached to move %d", i)
+			}
+		}
+	}
+	if comments := g.game.Comments(); len(comments) == 0 || len(comments[0]) != 1 || comments[0][0] != "comment e4" {
+		t.Errorf("expected the comment of the first move to be kept, got %v", comments)
+	}
+}
+
+func TestTakebacksSurviveFullTakeback(t *testing.T) {
+	g := newTakebackGame(t, []string{"e4", "e5"})
+	g.takeBack(&TakebackRequest{By: chess.Black, Moves: 1})
+	g.takeBack(&TakebackRequest{By: chess.White, Moves: 1})
+	if len(g.game.Moves()) != 0 || g.takebacks != 2 {
+		t.Fatalf("expected all moves taken back twice, got %d moves and %d takebacks", len(g.game.Moves()), g.takebacks)
+	}
+
+	pgn := pgnOf(t, g.game.String())
+	resumed := NewGame(newTestPlayer("black"), newTestPlayer("white"))
+	resumed.game = pgn
+	resumed.SetTakebackLimit(3)
+	resumed.restoreTakebacks()
+
+	if resumed.takebacksLeft() != 1 {
+		t.Errorf("expected 1 takeback left, got %d", resumed.takebacksLeft())
+	}
+}
+
+func TestRestoreTakebacksFromComments(t *testing.T) {
+	g := NewGame(newTestPlayer("black"), newTestPlayer("white"))
+	g.game = pgnOf(t, "1. e4 { "+TAKEBACK_COMMENT_PREFIX+" black: d5 } e5 *")
+	g.restoreTakebacks()
+
+	if g.takebacks != 1 {
+		t.Errorf("expected 1 takeback from the comments, got %d", g.takebacks)
+	}
+}
+
+func TestTakeBackRewindsClock(t *testing.T) {
+	tc, _ := ParseTimeControl("2/60:30", "")
+	g := newTakebackGame(t, []string{"e4", "e5", "Nf3", "Nc6"})
+	g.clock = NewClock(*tc)
+	g.clock.Set(chess.White, 90*time.Second, 2)
+	g.clock.Set(chess.Black, 90*time.Second, 2)
+
+	g.takeBack(&TakebackRequest{By: chess.Black, Moves: 1})
+	if g.clock.moves[chess.White] != 2 || g.clock.moves[chess.Black] != 1 {
+		t.Errorf("expected 2 white and 1 black moves, got %v", g.clock.moves)
+	}
+
+	// completing the first stage again adds its time once more
+	g.clock.Start(chess.Black)
+	if remaining := g.clock.Press(); remaining < 119*time.Second {
+		t.Errorf("expected the next stage to be added, got %s", remaining)
+	}
+
+	if err := g.undoMoves(3); err != nil {
+		t.Fatal(err)
+	}
+	if g.clock.moves[chess.White] != 0 || g.clock.moves[chess.Black] != 0 {
+		t.Errorf("expected no moves on the clock, got %v", g.clock.moves)
+	}
+	if !strings.Contains(g.game.String(), TAKEBACKS_TAG) {
+		t.Error("expected the takebacks tag")
+	}
+}
@@ -0,0 +1,135 @@
+package player
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/notnil/chess"
+	"github.com/windler/chesspal/pkg/dgt"
+)
+
+// ElectronicBoard is a physical board the moves of board players are made on
+type ElectronicBoard interface {
+	// Connect keeps the board connected and reconnects after it was unplugged
+	Connect(port, usbID string)
+	// DeviceInfo returns information about the connected board
+	DeviceInfo() DeviceInfo
+	// DeviceChannel receives the device info whenever it changes
+	DeviceChannel() chan DeviceInfo
+	// PostionChannel receives the pieces on the board whenever they changed
+	PostionChannel() chan chess.Board
+	// PromotionChannel receives the square of a pawn that has to be promoted but was not replaced on the board
+	PromotionChannel() chan chess.Square
+	// ButtonChannel receives the buttons pressed on a clock connected to the board
+	ButtonChannel() chan ClockButton
+
+	// MakeMove waits until a valid move was made on the board or the context is cancelled
+	MakeMove(ctx context.Context, game *chess.Game) (*chess.Move, error)
+	// Promote completes a pending promotion with the given piece
+	Promote(piece chess.PieceType) error
+	Reset()
+	SetUpsideDown(upsideDown bool)
+	UpsideDown() bool
+
+	// ShowMove lights the LEDs of the squares of a move on boards with LEDs
+	ShowMove(move *chess.Move) error
+	// ClearLEDs turns off all LEDs of the board
+	ClearLEDs() error
+
+	// SetClock sets the times of a clock connected to the board and starts the time of the given side
+	SetClock(left, right time.Duration, running ClockSide) error
+	// ClockText shows a text on a clock connected to the board
+	ClockText(text string, beep bool) error
+	// ClockEnd shows the times again after a text was displayed
+	ClockEnd() error
+}
+
+// ClockSide is a side of a clock connected to the board
+type ClockSide int
+
+const (
+	// CLOCK_SIDE_NONE stops both clocks
+	CLOCK_SIDE_NONE ClockSide = iota
+	CLOCK_SIDE_LEFT
+	CLOCK_SIDE_RIGHT
+)
+
+func (s ClockSide) String() string {
+	switch s {
+	case CLOCK_SIDE_LEFT:
+		return "left"
+	case CLOCK_SIDE_RIGHT:
+		return "right"
+	}
+	return ""
+}
+
+// ClockButton is one of the five buttons of a clock connected to the board, numbered from left to right
+type ClockButton int
+
+const (
+	CLOCK_BUTTON_BACK ClockButton = iota
+	CLOCK_BUTTON_MINUS
+	CLOCK_BUTTON_PLAY
+	CLOCK_BUTTON_PLUS
+	CLOCK_BUTTON_NEXT
+)
+
+// EventDecoder reads the events of a board
+type EventDecoder interface {
+	// Next blocks until an event was read
+	Next() (BoardEvent, error)
+}
+
+// BoardDriver covers the protocol of a board. The driver translates what its board sends into board events.
+type BoardDriver interface {
+	// Name is the type of the board shown in the device info
+	Name() string
+	// Open opens the connection to the board on the given port
+	Open(port string) (io.ReadWriteCloser, error)
+	// Decoder reads the events of the board from the connection
+	Decoder(r io.Reader) EventDecoder
+	// Init returns the commands sent after opening the board. They request the position and updates.
+	Init() [][]byte
+	// Query returns the commands that ask the board for its serial number, version and so on
+	Query() [][]byte
+	// Poll returns the command the board answers regularly to show it is still connected or nil if the board sends
+	// messages on its own
+	Poll() []byte
+	// LEDs returns the command to light the LEDs of the given squares or to turn them off if no square is given. The
+	// squares are those of the board in normal orientation. It returns nil if the board has no LEDs.
+	LEDs(squares ...chess.Square) []byte
+}
+
+// ClockDriver is a driver of a board a clock can be connected to
+type ClockDriver interface {
+	// SetClock returns the command that sets the times of the clock and starts the time of the given side
+	SetClock(left, right time.Duration, running ClockSide) []byte
+	// ClockText returns the command that shows a text on the clock
+	ClockText(text string, beep bool) []byte
+	// ClockEnd returns the command that shows the times again after a text was displayed
+	ClockEnd() []byte
+}
+
+// OrientedDriver is a driver that needs to know whether the board is upside down
+type OrientedDriver interface {
+	SetUpsideDown(upsideDown bool)
+}
+
+// SimulatedDriver is a driver of a board that does not speak the DGT protocol but can be simulated
+type SimulatedDriver interface {
+	// Simulate returns a connection to a board speaking the protocol of the driver that shows the pieces of the
+	// simulated board
+	Simulate(board *dgt.Simulator) io.ReadWriteCloser
+}
+
+// a8Index returns the index of a square on a board that is read rank by rank from a8 to h1
+func a8Index(sq chess.Square) int {
+	return (7-int(sq.Rank()))*8 + int(sq.File())
+}
+
+// a8Square returns the square of an index on a board that is read rank by rank from a8 to h1
+func a8Square(i int) chess.Square {
+	return chess.NewSquare(chess.File(i%8), chess.Rank(7-i/8))
+}
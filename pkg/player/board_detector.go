@@ -0,0 +1,212 @@
+package player
+
+import (
+	"sync"
+	"time"
+
+	"github.com/notnil/chess"
+	"github.com/windler/chesspal/pkg/game"
+)
+
+// DEFAULT_SETTLE_TIME is the time the board has to be unchanged before a move is detected. It allows "sliding" pieces
+// over other squares.
+const DEFAULT_SETTLE_TIME = 200 * time.Millisecond
+
+// PARTIAL_MOVE_FACTOR multiplies the settle time for moves that may be the first half of another move, e.g. a rook
+// move that may be followed by the king to castle
+const PARTIAL_MOVE_FACTOR = 5
+
+// BoardState describes the board compared to the position of the game
+type BoardState int
+
+const (
+	// BOARD_UNCHANGED shows the position of the game
+	BOARD_UNCHANGED BoardState = iota
+	// BOARD_LIFTED has one piece of the side to move lifted
+	BOARD_LIFTED
+	// BOARD_CAPTURE has a piece of each side lifted, e.g. while capturing
+	BOARD_CAPTURE
+	// BOARD_MOVED shows a legal move
+	BOARD_MOVED
+	// BOARD_PROMOTION has a pawn on the last rank that was not replaced by the promotion piece
+	BOARD_PROMOTION
+	// BOARD_UNKNOWN shows neither the position nor a move
+	BOARD_UNKNOWN
+)
+
+func (s BoardState) String() string {
+	switch s {
+	case BOARD_UNCHANGED:
+		return "unchanged"
+	case BOARD_LIFTED:
+		return "piece lifted"
+	case BOARD_CAPTURE:
+		return "capture"
+	case BOARD_MOVED:
+		return "moved"
+	case BOARD_PROMOTION:
+		return "promotion"
+	}
+	return "unknown"
+}
+
+// Detection is what a board shows compared to the position of the game
+type Detection struct {
+	State BoardState
+	// Move is the move of BOARD_MOVED
+	Move *chess.Move
+	// Partial is true if the move may be the first half of castling
+	Partial bool
+	// Promotions are the possible moves of BOARD_PROMOTION
+	Promotions []*chess.Move
+}
+
+// MoveDetector keeps track of the pieces on the board and waits until the board is stable before a move is detected
+type MoveDetector struct {
+	mutex  *sync.Mutex
+	settle time.Duration
+	pieces [64]chess.Piece
+	// generation is increased by every change, so a timer knows whether the board changed after it was started
+	generation int
+	// stop stops the last timer
+	stop func() bool
+	// afterFunc starts a timer. It is replaced to replay captures by their own times.
+	afterFunc func(d time.Duration, f func()) (stop func() bool)
+}
+
+func NewMoveDetector(settle time.Duration) *MoveDetector {
+	return &MoveDetector{
+		mutex:  &sync.Mutex{},
+		settle: settle,
+		afterFunc: func(d time.Duration, f func()) func() bool {
+			return time.AfterFunc(d, f).Stop
+		},
+	}
+}
+
+// SetSettleTime sets the time the board has to be unchanged before a move is detected
+func (d *MoveDetector) SetSettleTime(settle time.Duration) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.settle = settle
+}
+
+// SettleTime returns the time the board has to be unchanged before a move is detected
+func (d *MoveDetector) SettleTime() time.Duration {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.settle
+}
+
+// Update applies a position or square event. stable is called with the pieces once the board did not change for the
+// settle time.
+func (d *MoveDetector) Update(event BoardEvent, stable func(pieces [64]chess.Piece)) {
+	d.mutex.Lock()
+	d.apply(event)
+	settle := d.settle
+	d.mutex.Unlock()
+
+	d.Wait(settle, stable)
+}
+
+// apply changes the pieces by a position or square event. The mutex must be held by the caller.
+func (d *MoveDetector) apply(event BoardEvent) {
+	switch e := event.(type) {
+	case PositionEvent:
+		d.pieces = e.Pieces
+	case SquareEvent:
+		if int(e.Square) < len(d.pieces) {
+			d.pieces[e.Square] = e.Piece
+		}
+	}
+	d.generation++
+}
+
+// Wait calls stable with the pieces after the delay unless the board changes before
+func (d *MoveDetector) Wait(delay time.Duration, stable func(pieces [64]chess.Piece)) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.stop != nil {
+		d.stop()
+	}
+
+	generation := d.generation
+	d.stop = d.afterFunc(delay, func() {
+		d.mutex.Lock()
+		pieces := d.pieces
+		current := d.generation == generation
+		d.mutex.Unlock()
+
+		if current {
+			stable(pieces)
+		}
+	})
+}
+
+// DetectMove compares the board with the position of the game
+func DetectMove(pos chess.Position, board chess.Board) Detection {
+	diff := game.BoardDiff(*pos.Board(), board)
+	if len(diff) == 0 {
+		return Detection{State: BOARD_UNCHANGED}
+	}
+
+	move, promotions := detectPromotion(pos, board)
+	if move != nil {
+		return Detection{State: BOARD_MOVED, Move: move}
+	}
+
+	for _, valid := range pos.ValidMoves() {
+		if valid.Promo() == chess.NoPieceType && len(game.BoardDiff(*pos.Update(valid).Board(), board)) == 0 {
+			return Detection{State: BOARD_MOVED, Move: valid, Partial: isCastlingRookMove(pos, valid)}
+		}
+	}
+
+	if len(promotions) > 0 {
+		return Detection{State: BOARD_PROMOTION, Promotions: promotions}
+	}
+
+	return Detection{State: liftedState(pos, diff)}
+}
+
+// liftedState returns whether the differences are only lifted pieces of a move or capture in progress
+func liftedState(pos chess.Position, diff []game.SquareDiff) BoardState {
+	lifted := map[chess.Color]int{}
+	for _, d := range diff {
+		if d.Actual != chess.NoPiece {
+			return BOARD_UNKNOWN
+		}
+		lifted[d.Expected.Color()]++
+	}
+
+	switch {
+	case len(diff) == 1 && lifted[pos.Turn()] == 1:
+		return BOARD_LIFTED
+	case len(diff) == 2 && lifted[chess.White] == 1 && lifted[chess.Black] == 1:
+		return BOARD_CAPTURE
+	}
+	return BOARD_UNKNOWN
+}
+
+// isCastlingRookMove returns true if a rook moves to the square it would get by castling while castling is legal
+func isCastlingRookMove(pos chess.Position, move *chess.Move) bool {
+	if pos.Board().Piece(move.S1()).Type() != chess.Rook {
+		return false
+	}
+
+	for _, castling := range pos.ValidMoves() {
+		if !castling.HasTag(chess.KingSideCastle) && !castling.HasTag(chess.QueenSideCastle) {
+			continue
+		}
+
+		after := pos.Update(castling).Board()
+		if after.Piece(move.S2()).Type() == chess.Rook && pos.Board().Piece(move.S2()) == chess.NoPiece &&
+			after.Piece(move.S1()) == chess.NoPiece {
+			return true
+		}
+	}
+
+	return false
+}
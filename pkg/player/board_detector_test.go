@@ -0,0 +1,205 @@
+package player
+
+import (
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// position parses a FEN or fails the test
+func position(t *testing.T, fen string) *chess.Position {
+	t.Helper()
+	f, err := chess.FEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chess.NewGame(f).Position()
+}
+
+// boardOf returns the board of a FEN
+func boardOf(t *testing.T, fen string) chess.Board {
+	t.Helper()
+	return *position(t, fen).Board()
+}
+
+// changed returns the board of the FEN with the given squares changed. chess.NoPiece lifts the piece of a square.
+func changed(t *testing.T, fen string, changes map[chess.Square]chess.Piece) chess.Board {
+	t.Helper()
+	squares := position(t, fen).Board().SquareMap()
+	for sq, piece := range changes {
+		if piece == chess.NoPiece {
+			delete(squares, sq)
+		} else {
+			squares[sq] = piece
+		}
+	}
+	return *chess.NewBoard(squares)
+}
+
+func TestDetectMove(t *testing.T) {
+	const (
+		start     = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+		capture   = "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2"
+		enPassant = "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1"
+		castling  = "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1"
+		noRights  = "r3k2r/8/8/8/8/8/8/R3K2R w - - 0 1"
+	)
+	none := chess.NoPiece
+
+	tests := []struct {
+		name    string
+		fen     string
+		changes map[chess.Square]chess.Piece
+		state   BoardState
+		move    string
+		partial bool
+	}{
+		{name: "unchanged", fen: start, state: BOARD_UNCHANGED},
+		{name: "lifted", fen: start, changes: map[chess.Square]chess.Piece{chess.E2: none}, state: BOARD_LIFTED},
+		{name: "lifted piece of the opponent", fen: start, changes: map[chess.Square]chess.Piece{chess.E7: none}, state: BOARD_UNKNOWN},
+		{name: "two pieces lifted", fen: start, changes: map[chess.Square]chess.Piece{chess.E2: none, chess.D2: none}, state: BOARD_UNKNOWN},
+		{name: "quiet move", fen: start, changes: map[chess.Square]chess.Piece{chess.E2: none, chess.E4: chess.WhitePawn}, state: BOARD_MOVED, move: "e2e4"},
+		{name: "illegal move", fen: start, changes: map[chess.Square]chess.Piece{chess.E2: none, chess.E5: chess.WhitePawn}, state: BOARD_UNKNOWN},
+
+		{name: "capture in progress", fen: capture, changes: map[chess.Square]chess.Piece{chess.E4: none, chess.D5: none}, state: BOARD_CAPTURE},
+		{name: "captured piece lifted", fen: capture, changes: map[chess.Square]chess.Piece{chess.D5: none}, state: BOARD_UNKNOWN},
+		{name: "capture", fen: capture, changes: map[chess.Square]chess.Piece{chess.E4: none, chess.D5: chess.WhitePawn}, state: BOARD_MOVED, move: "e4d5"},
+
+		{name: "en passant", fen: enPassant, changes: map[chess.Square]chess.Piece{chess.E5: none, chess.D5: none, chess.D6: chess.WhitePawn}, state: BOARD_MOVED, move: "e5d6"},
+		{name: "en passant without removing the pawn", fen: enPassant, changes: map[chess.Square]chess.Piece{chess.E5: none, chess.D6: chess.WhitePawn}, state: BOARD_UNKNOWN},
+
+		{name: "castling rook first", fen: castling, changes: map[chess.Square]chess.Piece{chess.H1: none, chess.F1: chess.WhiteRook}, state: BOARD_MOVED, move: "h1f1", partial: true},
+		{name: "queen side castling rook first", fen: castling, changes: map[chess.Square]chess.Piece{chess.A1: none, chess.D1: chess.WhiteRook}, state: BOARD_MOVED, move: "a1d1", partial: true},
+		{name: "castling king first", fen: castling, changes: map[chess.Square]chess.Piece{chess.E1: none, chess.G1: chess.WhiteKing}, state: BOARD_UNKNOWN},
+		{name: "castling", fen: castling, changes: map[chess.Square]chess.Piece{chess.E1: none, chess.H1: none, chess.G1: chess.WhiteKing, chess.F1: chess.WhiteRook}, state: BOARD_MOVED, move: "e1g1"},
+		{name: "rook move without castling rights", fen: noRights, changes: map[chess.Square]chess.Piece{chess.H1: none, chess.F1: chess.WhiteRook}, state: BOARD_MOVED, move: "h1f1"},
+
+		{name: "promotion", fen: "8/P6k/8/8/8/8/8/K7 w - - 0 1", changes: map[chess.Square]chess.Piece{chess.A7: none, chess.A8: chess.WhiteQueen}, state: BOARD_MOVED, move: "a7a8q"},
+		{name: "promotion without piece", fen: "8/P6k/8/8/8/8/8/K7 w - - 0 1", changes: map[chess.Square]chess.Piece{chess.A7: none, chess.A8: chess.WhitePawn}, state: BOARD_PROMOTION},
+	}
+
+	for _, test := range tests {
+		detection := DetectMove(*position(t, test.fen), changed(t, test.fen, test.changes))
+		if detection.State != test.state {
+			t.Errorf("%s: expected %s, got %s", test.name, test.state, detection.State)
+			continue
+		}
+		if test.move == "" {
+			continue
+		}
+		if detection.Move == nil || detection.Move.String() != test.move {
+			t.Errorf("%s: expected %s, got %v", test.name, test.move, detection.Move)
+		}
+		if detection.Partial != test.partial {
+			t.Errorf("%s: expected partial %v, got %v", test.name, test.partial, detection.Partial)
+		}
+	}
+}
+
+// stablePieces collects the pieces the detector reports as stable
+func stablePieces() (chan [64]chess.Piece, func(pieces [64]chess.Piece)) {
+	stable := make(chan [64]chess.Piece, 16)
+	return stable, func(pieces [64]chess.Piece) {
+		stable <- pieces
+	}
+}
+
+// expectStable fails unless the detector reports the board stable with the piece on the square within the timeout
+func expectStable(t *testing.T, stable chan [64]chess.Piece, sq chess.Square, piece chess.Piece, timeout time.Duration) {
+	t.Helper()
+	select {
+	case pieces := <-stable:
+		if actual := pieces[sq]; actual != piece {
+			t.Errorf("expected %s on %s, got %s", piece, sq, actual)
+		}
+	case <-time.After(timeout):
+		t.Error("expected the board to be stable")
+	}
+}
+
+// expectUnstable fails if the detector reports the board stable within the duration
+func expectUnstable(t *testing.T, stable chan [64]chess.Piece, d time.Duration) {
+	t.Helper()
+	select {
+	case <-stable:
+		t.Error("the board must not be stable yet")
+	case <-time.After(d):
+	}
+}
+
+func TestMoveDetectorSettles(t *testing.T) {
+	settle := 50 * time.Millisecond
+	detector := NewMoveDetector(settle)
+	stable, report := stablePieces()
+
+	// sliding the piece over several squares is reported once after the last square
+	for _, sq := range []chess.Square{chess.E2, chess.E3, chess.E4} {
+		if sq != chess.E2 {
+			detector.Update(SquareEvent{Square: sq, Piece: chess.WhitePawn}, report)
+		}
+		detector.Update(SquareEvent{Square: sq, Piece: chess.NoPiece}, report)
+	}
+	detector.Update(SquareEvent{Square: chess.E4, Piece: chess.WhitePawn}, report)
+
+	expectUnstable(t, stable, settle/2)
+	expectStable(t, stable, chess.E4, chess.WhitePawn, settle*4)
+	expectUnstable(t, stable, settle*2)
+}
+
+func TestMoveDetectorSettleTimeExpires(t *testing.T) {
+	settle := 20 * time.Millisecond
+	detector := NewMoveDetector(settle)
+	stable, report := stablePieces()
+
+	var position PositionEvent
+	position.Pieces[chess.E2] = chess.WhitePawn
+	detector.Update(position, report)
+	expectStable(t, stable, chess.E2, chess.WhitePawn, settle*10)
+
+	// every change after the settle time is reported
+	detector.Update(SquareEvent{Square: chess.E2, Piece: chess.NoPiece}, report)
+	expectStable(t, stable, chess.E2, chess.NoPiece, settle*10)
+	detector.Update(SquareEvent{Square: chess.E4, Piece: chess.WhitePawn}, report)
+	expectStable(t, stable, chess.E4, chess.WhitePawn, settle*10)
+
+	detector.SetSettleTime(2 * settle)
+	if detector.SettleTime() != 2*settle {
+		t.Errorf("expected the settle time %s, got %s", 2*settle, detector.SettleTime())
+	}
+}
+
+func TestMoveDetectorWaitIsCancelledByChanges(t *testing.T) {
+	settle := 20 * time.Millisecond
+	detector := NewMoveDetector(settle)
+	stable, report := stablePieces()
+
+	// a partial move waits longer, but completing it is reported after the settle time
+	detector.Wait(settle*PARTIAL_MOVE_FACTOR, report)
+	detector.Update(SquareEvent{Square: chess.G1, Piece: chess.WhiteKing}, report)
+	expectStable(t, stable, chess.G1, chess.WhiteKing, settle*3)
+	expectUnstable(t, stable, settle*PARTIAL_MOVE_FACTOR)
+}
+
+func TestDetectMoveChess960Castling(t *testing.T) {
+	tests := []struct {
+		fen   string
+		board string
+		move  string
+	}{
+		{fen: "1r4kr/pppppppp/8/8/8/8/PPPPPPPP/1R4KR w HBhb - 0 1", board: "1r4kr/pppppppp/8/8/8/8/PPPPPPPP/1R3RK1 w - - 0 1", move: "g1h1"},
+		{fen: "1r4kr/pppppppp/8/8/8/8/PPPPPPPP/1R4KR w HBhb - 0 1", board: "1r4kr/pppppppp/8/8/8/8/PPPPPPPP/2KR3R w - - 0 1", move: "g1b1"},
+		{fen: "1r3kr1/pppppppp/8/8/8/8/PPPPPPPP/1R3KR1 w GBgb - 0 1", board: "1r3kr1/pppppppp/8/8/8/8/PPPPPPPP/1R3RK1 w - - 0 1", move: "f1g1"},
+	}
+
+	for _, test := range tests {
+		detection := DetectMove(*position(t, test.fen), boardOf(t, test.board))
+		if detection.State != BOARD_MOVED || detection.Move.String() != test.move {
+			t.Errorf("%s: expected castling %s, got %s %v", test.fen, test.move, detection.State, detection.Move)
+			continue
+		}
+		if !detection.Move.HasTag(chess.KingSideCastle) && !detection.Move.HasTag(chess.QueenSideCastle) {
+			t.Errorf("%s: %s is not tagged as castling", test.fen, test.move)
+		}
+	}
+}
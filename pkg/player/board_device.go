@@ -0,0 +1,133 @@
+package player
+
+import (
+	"log"
+	"time"
+)
+
+// DEVICE_POLL_INTERVAL is the interval the board is asked for a sign of life
+const DEVICE_POLL_INTERVAL = 5 * time.Second
+
+// DEVICE_TIMEOUT is the time without any message after which the board is considered disconnected
+const DEVICE_TIMEOUT = 3 * DEVICE_POLL_INTERVAL
+
+// DeviceInfo describes the connected board
+type DeviceInfo struct {
+	Type         string `json:"type"`
+	Port         string `json:"port"`
+	Connected    bool   `json:"connected"`
+	SerialNumber string `json:"serialNumber"`
+	Trademark    string `json:"trademark"`
+	Version      string `json:"version"`
+	// Battery is the remaining battery capacity in percent or -1 for boards without battery
+	Battery int  `json:"battery"`
+	Clock   bool `json:"clock"`
+	// ClockLeftMs and ClockRightMs are the times shown by a connected clock
+	ClockLeftMs  int64 `json:"clockLeftMs"`
+	ClockRightMs int64 `json:"clockRightMs"`
+	// ClockRunning is the side of the clock whose time is running, "left", "right" or empty
+	ClockRunning string    `json:"clockRunning"`
+	LastSeen     time.Time `json:"lastSeen"`
+}
+
+// DeviceChannel receives the device info whenever it changes
+func (p *BoardEngine) DeviceChannel() chan DeviceInfo {
+	return p.deviceChan
+}
+
+// DeviceInfo returns information about the connected board
+func (p *BoardEngine) DeviceInfo() DeviceInfo {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.device
+}
+
+// queryDevice asks the board for its serial number, trademark, version and battery status
+func (p *BoardEngine) queryDevice() {
+	for _, cmd := range p.driver.Query() {
+		if err := p.write(cmd...); err != nil {
+			log.Printf("error querying board: %v", err)
+			return
+		}
+	}
+}
+
+// pollDevice pings the board regularly and disconnects it if it stops answering
+func (p *BoardEngine) pollDevice() {
+	ticker := time.NewTicker(DEVICE_POLL_INTERVAL)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if cmd := p.driver.Poll(); cmd != nil {
+			if err := p.write(cmd...); err != nil {
+				log.Printf("error polling board: %v", err)
+			}
+		}
+
+		p.mutex.Lock()
+		lost := p.device.Connected && time.Since(p.device.LastSeen) > DEVICE_TIMEOUT
+		p.mutex.Unlock()
+
+		if lost {
+			// closing the port ends the read loop and lets the engine reconnect
+			log.Println("Board stopped answering")
+			p.disconnect()
+		}
+	}
+}
+
+// seen updates the device info with an event received from the board
+func (p *BoardEngine) seen(event BoardEvent) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	changed := !p.device.Connected
+	if changed {
+		log.Printf("Board on %s connected", p.device.Port)
+		go p.queryDevice()
+	}
+	p.device.Connected = true
+	p.device.LastSeen = time.Now()
+
+	switch e := event.(type) {
+	case DeviceEvent:
+		changed = update(&p.device.SerialNumber, e.SerialNumber) || changed
+		changed = update(&p.device.Trademark, e.Trademark) || changed
+		changed = update(&p.device.Version, e.Version) || changed
+	case BatteryEvent:
+		changed = changed || p.device.Battery != e.Percent
+		p.device.Battery = e.Percent
+	case ClockEvent:
+		clock := p.device
+		clock.Clock = e.Connected
+		clock.ClockLeftMs = e.Left.Milliseconds()
+		clock.ClockRightMs = e.Right.Milliseconds()
+		clock.ClockRunning = e.Running.String()
+		changed = changed || clock != p.device
+		p.device = clock
+	}
+
+	if changed {
+		p.publishDevice()
+	}
+}
+
+// update sets a property of the device info if it was reported and returns true if it changed
+func update(property *string, reported string) bool {
+	if reported == "" || reported == *property {
+		return false
+	}
+	*property = reported
+	return true
+}
+
+// publishDevice announces the device info. The mutex must be held by the caller.
+func (p *BoardEngine) publishDevice() {
+	// only the latest info is of interest
+	select {
+	case <-p.deviceChan:
+	default:
+	}
+	p.deviceChan <- p.device
+}
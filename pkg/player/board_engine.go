@@ -0,0 +1,523 @@
+package player
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/notnil/chess"
+	"github.com/windler/chesspal/pkg/dgt"
+	"github.com/windler/chesspal/pkg/game"
+)
+
+var ErrNoPromotion = errors.New("no promotion pending")
+var ErrBoardNotConnected = errors.New("board is not connected")
+
+// PLAYER_TYPE_BOARD is the type of board players saved with the game
+const PLAYER_TYPE_BOARD = "board"
+
+// BoardPlayer makes its moves on an electronic board
+type BoardPlayer struct {
+	engine ElectronicBoard
+	name   string
+}
+
+// BoardEngine drives an electronic board. The protocol of the board is covered by its BoardDriver.
+type BoardEngine struct {
+	io    io.ReadWriteCloser
+	mutex *sync.Mutex
+	moved chan *chess.Move
+	game  *chess.Game
+	// upsideDown is guarded by the mutex as it is read by the read loop
+	upsideDown   bool
+	positionChan chan chess.Board
+	// promotions are the possible moves of a pawn placed on the last rank without replacing it
+	promotions    []*chess.Move
+	promotionChan chan chess.Square
+	writeMutex    *sync.Mutex
+	buttonChan    chan ClockButton
+	device        DeviceInfo
+	deviceChan    chan DeviceInfo
+	open          func(port string) (io.ReadWriteCloser, error)
+	capture       *dgt.Recorder
+	detector      *MoveDetector
+	driver        BoardDriver
+}
+
+func NewBoardPlayer(name string, engine ElectronicBoard) *BoardPlayer {
+	log.Printf("Board player created ")
+	return &BoardPlayer{
+		engine: engine,
+		name:   name,
+	}
+}
+
+func (p *BoardPlayer) IsBot() bool {
+	return false
+}
+
+// Type is saved with the game to play on the board again when the game is resumed
+func (p *BoardPlayer) Type() string {
+	return PLAYER_TYPE_BOARD
+}
+
+func (p *BoardPlayer) Name() string {
+	return p.name
+}
+
+func NewBoardEngine() *BoardEngine {
+	return &BoardEngine{
+		mutex:         &sync.Mutex{},
+		positionChan:  make(chan chess.Board),
+		promotionChan: make(chan chess.Square, 1),
+		writeMutex:    &sync.Mutex{},
+		buttonChan:    make(chan ClockButton, 8),
+		device:        DeviceInfo{Battery: -1},
+		deviceChan:    make(chan DeviceInfo, 1),
+		open:          USBDriver{}.Open,
+		driver:        USBDriver{},
+		detector:      NewMoveDetector(DEFAULT_SETTLE_TIME),
+	}
+}
+
+// SetDriver sets the driver of the connected board, e.g. a PegasusDriver or a MillenniumDriver. The port is opened by the driver.
+func (p *BoardEngine) SetDriver(driver BoardDriver) {
+	p.driver = driver
+	if oriented, ok := driver.(OrientedDriver); ok {
+		oriented.SetUpsideDown(p.UpsideDown())
+	}
+	p.open = driver.Open
+}
+
+// SetOpener replaces how the port of the board is opened, e.g. to connect to a simulated board
+func (p *BoardEngine) SetOpener(open func(port string) (io.ReadWriteCloser, error)) {
+	p.open = open
+}
+
+// Simulate connects the engine to the simulated board instead of the port. Boards that do not speak the DGT protocol
+// are simulated if their driver is a SimulatedDriver.
+func (p *BoardEngine) Simulate(board *dgt.Simulator) {
+	connect := board.Connect
+	if simulated, ok := p.driver.(SimulatedDriver); ok {
+		connect = func() io.ReadWriteCloser {
+			return simulated.Simulate(board)
+		}
+	}
+	p.SetOpener(func(string) (io.ReadWriteCloser, error) {
+		return connect(), nil
+	})
+}
+
+// SetSettleTime sets the time the board has to be unchanged before a move is detected. Slow players may need more
+// time to slide pieces.
+func (p *BoardEngine) SetSettleTime(settle time.Duration) {
+	p.detector.SetSettleTime(settle)
+}
+
+// SetCapture records every byte read from and written to the board
+func (p *BoardEngine) SetCapture(recorder *dgt.Recorder) {
+	p.capture = recorder
+}
+
+func (p *BoardEngine) PostionChannel() chan chess.Board {
+	return p.positionChan
+}
+
+// PromotionChannel receives the square of a pawn that has to be promoted but was not replaced on the board.
+// chess.NoSquare is sent once the promotion is no longer pending.
+func (p *BoardEngine) PromotionChannel() chan chess.Square {
+	return p.promotionChan
+}
+
+// ButtonChannel receives the buttons pressed on a clock connected to the board
+func (p *BoardEngine) ButtonChannel() chan ClockButton {
+	return p.buttonChan
+}
+
+// SetClock sets the times of a clock connected to the board and starts the time of the given side
+func (p *BoardEngine) SetClock(left, right time.Duration, running ClockSide) error {
+	if clock, ok := p.driver.(ClockDriver); ok {
+		return p.write(clock.SetClock(left, right, running)...)
+	}
+	return nil
+}
+
+// ClockText shows a text on a clock connected to the board
+func (p *BoardEngine) ClockText(text string, beep bool) error {
+	if clock, ok := p.driver.(ClockDriver); ok {
+		return p.write(clock.ClockText(text, beep)...)
+	}
+	return nil
+}
+
+// ClockEnd shows the times again after a text was displayed
+func (p *BoardEngine) ClockEnd() error {
+	if clock, ok := p.driver.(ClockDriver); ok {
+		return p.write(clock.ClockEnd()...)
+	}
+	return nil
+}
+
+func (p *BoardEngine) write(b ...byte) error {
+	p.writeMutex.Lock()
+	defer p.writeMutex.Unlock()
+
+	if p.io == nil {
+		return ErrBoardNotConnected
+	}
+
+	_, err := p.io.Write(b)
+	return err
+}
+
+// ShowMove lights the LEDs of the squares of a move on boards with LEDs
+func (p *BoardEngine) ShowMove(move *chess.Move) error {
+	upsideDown := p.UpsideDown()
+	return p.setLEDs(boardSquare(move.S1(), upsideDown), boardSquare(move.S2(), upsideDown))
+}
+
+// ClearLEDs turns off all LEDs of the board
+func (p *BoardEngine) ClearLEDs() error {
+	return p.setLEDs()
+}
+
+func (p *BoardEngine) setLEDs(squares ...chess.Square) error {
+	cmd := p.driver.LEDs(squares...)
+	if cmd == nil {
+		return nil
+	}
+	return p.write(cmd...)
+}
+
+func (p *BoardEngine) Reset() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.game = nil
+	p.setPromotions(nil)
+}
+
+func (p *BoardEngine) SetUpsideDown(ud bool) {
+	p.mutex.Lock()
+	p.upsideDown = ud
+	p.mutex.Unlock()
+
+	if driver, ok := p.driver.(OrientedDriver); ok {
+		driver.SetUpsideDown(ud)
+	}
+}
+
+func (p *BoardEngine) UpsideDown() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.upsideDown
+}
+
+// MakeMove waits until a valid move was made on the board or the context is cancelled
+func (p *BoardEngine) MakeMove(ctx context.Context, game *chess.Game) (*chess.Move, error) {
+	p.mutex.Lock()
+	p.game = game
+	p.moved = make(chan *chess.Move, 1)
+	moved := p.moved
+	p.setPromotions(nil)
+	p.mutex.Unlock()
+
+	select {
+	case move := <-moved:
+		return move, nil
+	case <-ctx.Done():
+		p.mutex.Lock()
+		if p.moved == moved {
+			p.game = nil
+			p.setPromotions(nil)
+		}
+		p.mutex.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// RECONNECT_DELAY is the time to wait before looking for the board again
+const RECONNECT_DELAY = 1 * time.Second
+
+// Connect keeps the board connected and reconnects after it was unplugged. If an USB id is given, the port is looked up
+// by the id of the USB device so the board is found even if it appears on another port after being plugged in again.
+func (p *BoardEngine) Connect(port, usbID string) {
+	go p.pollDevice()
+
+	for {
+		name := port
+		if usbID != "" {
+			found, err := FindUSBPort(usbID)
+			if err != nil {
+				log.Println(err)
+				time.Sleep(RECONNECT_DELAY)
+				continue
+			}
+			name = found
+		}
+
+		if err := p.Start(name); err != nil {
+			if errors.Is(err, dgt.ErrReplayFinished) {
+				log.Println("Replay of the board finished")
+				return
+			}
+			log.Println(err.Error())
+			time.Sleep(RECONNECT_DELAY)
+			continue
+		}
+
+		p.readLoop()
+		time.Sleep(RECONNECT_DELAY)
+	}
+}
+
+// Start opens the port of the board and asks for the current position
+func (p *BoardEngine) Start(port string) error {
+	p.mutex.Lock()
+	p.device.Type = p.driver.Name()
+	p.device.Port = port
+	p.mutex.Unlock()
+
+	conn, err := p.open(port)
+	if err != nil {
+		return err
+	}
+	if p.capture != nil {
+		conn = p.capture.Wrap(port, conn)
+	}
+
+	for _, cmd := range p.driver.Init() {
+		if _, err := conn.Write(cmd); err != nil {
+			conn.Close()
+			return fmt.Errorf("error initializing board on %s: %w", port, err)
+		}
+	}
+
+	p.writeMutex.Lock()
+	p.io = conn
+	p.writeMutex.Unlock()
+
+	log.Printf("Serial port %s opened", port)
+	return nil
+}
+
+// disconnect closes the serial port so the board can be connected again
+func (p *BoardEngine) disconnect() {
+	p.writeMutex.Lock()
+	if p.io != nil {
+		p.io.Close()
+		p.io = nil
+	}
+	p.writeMutex.Unlock()
+
+	p.mutex.Lock()
+	if p.device.Connected {
+		log.Printf("Board on %s disconnected", p.device.Port)
+		p.device.Connected = false
+		p.publishDevice()
+	}
+	p.mutex.Unlock()
+}
+
+func (p *BoardEngine) ReadCurrentPosition() {
+	for _, cmd := range p.driver.Init() {
+		if err := p.write(cmd...); err != nil {
+			log.Printf("error reading the position: %v", err)
+			return
+		}
+	}
+}
+
+// readLoop handles the events of the board until reading fails, e.g. because the board was unplugged
+func (p *BoardEngine) readLoop() {
+	p.writeMutex.Lock()
+	port := p.io
+	p.writeMutex.Unlock()
+	if port == nil {
+		return
+	}
+
+	decoder := p.driver.Decoder(port)
+	for {
+		event, err := decoder.Next()
+		if err != nil {
+			log.Printf("error reading bytes from serial port: %s\n", err)
+			p.disconnect()
+			return
+		}
+
+		p.seen(event)
+		p.handle(event)
+	}
+}
+
+// handle passes the pieces on the board to the move detection and the buttons of the clock to the session
+func (p *BoardEngine) handle(event BoardEvent) {
+	switch e := event.(type) {
+	case ButtonEvent:
+		p.pressed(e.Button)
+	case PositionEvent, SquareEvent:
+		p.detector.Update(event, func(pieces [64]chess.Piece) {
+			p.boardStable(pieces, false)
+		})
+	}
+}
+
+// boardStable detects moves once the board did not change for the settle time. Moves that may be the first half of
+// another move are only committed if the board stays unchanged even longer.
+func (p *BoardEngine) boardStable(pieces [64]chess.Piece, late bool) {
+	board := p.chessBoard(pieces)
+	p.positionChan <- board
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.game == nil || p.game.Outcome() != chess.NoOutcome {
+		return
+	}
+
+	detection := DetectMove(*p.game.Position(), board)
+	switch detection.State {
+	case BOARD_MOVED:
+		if detection.Partial && !late {
+			p.detector.Wait(p.detector.SettleTime()*PARTIAL_MOVE_FACTOR, func(pieces [64]chess.Piece) {
+				p.boardStable(pieces, true)
+			})
+			return
+		}
+		p.commit(detection.Move)
+	case BOARD_PROMOTION:
+		log.Printf("Pawn moved to %s without promotion piece", detection.Promotions[0].S2())
+		p.setPromotions(detection.Promotions)
+	case BOARD_LIFTED:
+		// a pending promotion stays pending while the pawn is replaced
+	default:
+		p.setPromotions(nil)
+	}
+}
+
+// pressed hands a button pressed on the clock to the session
+func (p *BoardEngine) pressed(button ClockButton) {
+	log.Printf("Clock button %d pressed", button)
+	select {
+	case p.buttonChan <- button:
+	default:
+		log.Println("Clock button dropped")
+	}
+}
+
+// commit hands a move to the waiting player. The mutex must be held by the caller.
+func (p *BoardEngine) commit(move *chess.Move) {
+	p.game = nil
+	p.setPromotions(nil)
+	p.moved <- move
+	log.Printf("Valid move found: %s\n", move)
+}
+
+// Promote completes a pending promotion with the given piece, e.g. chosen in the web UI
+func (p *BoardEngine) Promote(piece chess.PieceType) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.game == nil {
+		return ErrNoPromotion
+	}
+
+	for _, move := range p.promotions {
+		if move.Promo() == piece {
+			p.commit(move)
+			return nil
+		}
+	}
+
+	return ErrNoPromotion
+}
+
+// setPromotions stores the pending promotion moves and announces changes. The mutex must be held by the caller.
+func (p *BoardEngine) setPromotions(promotions []*chess.Move) {
+	if len(promotions) == 0 && len(p.promotions) == 0 {
+		return
+	}
+	if len(promotions) > 0 && len(p.promotions) > 0 && promotions[0].String() == p.promotions[0].String() {
+		return
+	}
+	p.promotions = promotions
+
+	square := chess.NoSquare
+	if len(promotions) > 0 {
+		square = promotions[0].S2()
+	}
+
+	// only the latest announcement is of interest
+	select {
+	case <-p.promotionChan:
+	default:
+	}
+	p.promotionChan <- square
+}
+
+// detectPromotion returns the promotion the board shows by the piece placed on the last rank. If the pawn was placed
+// there without being replaced, all possible promotions of the pawn are returned instead.
+func detectPromotion(pos chess.Position, board chess.Board) (*chess.Move, []*chess.Move) {
+	promotions := []*chess.Move{}
+
+	for _, move := range pos.ValidMoves() {
+		if move.Promo() == chess.NoPieceType {
+			continue
+		}
+
+		diff := game.BoardDiff(*pos.Update(move).Board(), board)
+		if len(diff) == 0 {
+			return move, nil
+		}
+
+		placed := board.Piece(move.S2())
+		if len(diff) == 1 && diff[0].Square == move.S2() && placed.Type() == chess.Pawn && placed.Color() == pos.Turn() {
+			promotions = append(promotions, move)
+		}
+	}
+
+	return nil, promotions
+}
+
+func (p *BoardPlayer) SetColor(color chess.Color) {
+}
+
+func (p *BoardPlayer) MakeMove(ctx context.Context, game *chess.Game) (*chess.Move, error) {
+	move, err := p.engine.MakeMove(ctx, game)
+	if err == nil {
+		log.Printf("Board player %s moved\n", p.name)
+	}
+	return move, err
+}
+
+func (p *BoardPlayer) End() {
+
+}
+
+// chessBoard returns the position shown by the pieces on the squares of the board
+func (p *BoardEngine) chessBoard(pieces [64]chess.Piece) chess.Board {
+	upsideDown := p.UpsideDown()
+
+	squares := map[chess.Square]chess.Piece{}
+	for sq, piece := range pieces {
+		if piece != chess.NoPiece {
+			squares[boardSquare(chess.Square(sq), upsideDown)] = piece
+		}
+	}
+
+	return *chess.NewBoard(squares)
+}
+
+// boardSquare turns a square of the board into a square of the game and back if the board is upside down
+func boardSquare(sq chess.Square, upsideDown bool) chess.Square {
+	if upsideDown {
+		return chess.Square(63 - int(sq))
+	}
+	return sq
+}
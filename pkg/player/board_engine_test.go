@@ -0,0 +1,188 @@
+package player
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+const promotionFEN = "1r5k/P7/8/8/8/8/8/K7 w - - 0 1"
+
+func TestDetectPromotion(t *testing.T) {
+	none := chess.NoPiece
+
+	tests := []struct {
+		name       string
+		changes    map[chess.Square]chess.Piece
+		move       string
+		promotions []string
+	}{
+		{name: "queen", changes: map[chess.Square]chess.Piece{chess.A7: none, chess.A8: chess.WhiteQueen}, move: "a7a8q"},
+		{name: "knight", changes: map[chess.Square]chess.Piece{chess.A7: none, chess.A8: chess.WhiteKnight}, move: "a7a8n"},
+		{name: "rook", changes: map[chess.Square]chess.Piece{chess.A7: none, chess.A8: chess.WhiteRook}, move: "a7a8r"},
+		{name: "bishop", changes: map[chess.Square]chess.Piece{chess.A7: none, chess.A8: chess.WhiteBishop}, move: "a7a8b"},
+		{name: "capture with a knight", changes: map[chess.Square]chess.Piece{chess.A7: none, chess.B8: chess.WhiteKnight}, move: "a7b8n"},
+		{name: "capture with a queen", changes: map[chess.Square]chess.Piece{chess.A7: none, chess.B8: chess.WhiteQueen}, move: "a7b8q"},
+		{name: "pawn on the last rank", changes: map[chess.Square]chess.Piece{chess.A7: none, chess.A8: chess.WhitePawn},
+			promotions: []string{"a7a8q", "a7a8r", "a7a8b", "a7a8n"}},
+		{name: "pawn captured on the last rank", changes: map[chess.Square]chess.Piece{chess.A7: none, chess.B8: chess.WhitePawn},
+			promotions: []string{"a7b8q", "a7b8r", "a7b8b", "a7b8n"}},
+		{name: "piece of the opponent", changes: map[chess.Square]chess.Piece{chess.A7: none, chess.A8: chess.BlackQueen}},
+		{name: "pawn lifted", changes: map[chess.Square]chess.Piece{chess.A7: none}},
+		{name: "piece without removing the pawn", changes: map[chess.Square]chess.Piece{chess.A8: chess.WhiteQueen}},
+	}
+
+	for _, test := range tests {
+		move, promotions := detectPromotion(*position(t, promotionFEN), changed(t, promotionFEN, test.changes))
+
+		if test.move != "" {
+			if move == nil || move.String() != test.move {
+				t.Errorf("%s: expected %s, got %v", test.name, test.move, move)
+			}
+			continue
+		}
+		if move != nil {
+			t.Errorf("%s: expected no move, got %s", test.name, move)
+		}
+
+		found := map[string]bool{}
+		for _, p := range promotions {
+			found[p.String()] = true
+		}
+		if len(promotions) != len(test.promotions) {
+			t.Errorf("%s: expected promotions %v, got %v", test.name, test.promotions, promotions)
+			continue
+		}
+		for _, p := range test.promotions {
+			if !found[p] {
+				t.Errorf("%s: expected promotion %s, got %v", test.name, p, promotions)
+			}
+		}
+	}
+}
+
+// piecesOf returns the pieces a board in normal orientation shows for a chess board
+func piecesOf(board chess.Board) [64]chess.Piece {
+	var pieces [64]chess.Piece
+	for sq, piece := range board.SquareMap() {
+		pieces[sq] = piece
+	}
+	return pieces
+}
+
+// waitingEngine returns an engine waiting for a move in the game and the channel receiving the move
+func waitingEngine(t *testing.T, game *chess.Game) (*BoardEngine, chan *chess.Move) {
+	t.Helper()
+	e := NewBoardEngine()
+	go func() {
+		for range e.PostionChannel() {
+		}
+	}()
+
+	moved := make(chan *chess.Move, 1)
+	go func() {
+		move, err := e.MakeMove(context.Background(), game)
+		if err == nil {
+			moved <- move
+		}
+	}()
+
+	for i := 0; ; i++ {
+		e.mutex.Lock()
+		waiting := e.game != nil
+		e.mutex.Unlock()
+		if waiting {
+			return e, moved
+		}
+		if i > 100 {
+			t.Fatal("the engine does not wait for a move")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func expectPromotionSquare(t *testing.T, e *BoardEngine, expected chess.Square) {
+	t.Helper()
+	select {
+	case sq := <-e.PromotionChannel():
+		if sq != expected {
+			t.Errorf("expected the promotion square %s, got %s", expected, sq)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("expected the promotion square %s", expected)
+	}
+}
+
+func expectMove(t *testing.T, moved chan *chess.Move, expected string) {
+	t.Helper()
+	select {
+	case move := <-moved:
+		if move.String() != expected {
+			t.Errorf("expected %s, got %s", expected, move)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("expected the move %s", expected)
+	}
+}
+
+func TestPromotionPrompt(t *testing.T) {
+	fen, _ := chess.FEN(promotionFEN)
+	e, moved := waitingEngine(t, chess.NewGame(fen))
+
+	if err := e.Promote(chess.Queen); err != ErrNoPromotion {
+		t.Errorf("expected no promotion to be pending, got %v", err)
+	}
+
+	// the pawn is placed on the last rank, the UI is asked for the piece
+	e.boardStable(piecesOf(changed(t, promotionFEN, map[chess.Square]chess.Piece{chess.A7: chess.NoPiece, chess.A8: chess.WhitePawn})), false)
+	expectPromotionSquare(t, e, chess.A8)
+
+	// lifting the pawn to replace it keeps the promotion pending
+	e.boardStable(piecesOf(changed(t, promotionFEN, map[chess.Square]chess.Piece{chess.A7: chess.NoPiece})), false)
+	if err := e.Promote(chess.Knight); err != nil {
+		t.Fatal(err)
+	}
+	expectMove(t, moved, "a7a8n")
+}
+
+func TestPromotionPromptWithdrawn(t *testing.T) {
+	fen, _ := chess.FEN(promotionFEN)
+	e, moved := waitingEngine(t, chess.NewGame(fen))
+
+	e.boardStable(piecesOf(changed(t, promotionFEN, map[chess.Square]chess.Piece{chess.A7: chess.NoPiece, chess.B8: chess.WhitePawn})), false)
+	expectPromotionSquare(t, e, chess.B8)
+
+	// the pawn is taken back, so the prompt is withdrawn
+	e.boardStable(piecesOf(changed(t, promotionFEN, nil)), false)
+	expectPromotionSquare(t, e, chess.NoSquare)
+	if err := e.Promote(chess.Queen); err != ErrNoPromotion {
+		t.Errorf("expected no promotion to be pending, got %v", err)
+	}
+
+	// replacing the pawn by a piece on the board does not need the UI
+	e.boardStable(piecesOf(changed(t, promotionFEN, map[chess.Square]chess.Piece{chess.A7: chess.NoPiece, chess.B8: chess.WhiteRook})), false)
+	expectMove(t, moved, "a7b8r")
+}
+
+func TestDeviceClock(t *testing.T) {
+	e := NewBoardEngine()
+	e.seen(ClockEvent{Left: 5 * time.Minute, Right: 90 * time.Second, Running: CLOCK_SIDE_LEFT, Connected: true})
+
+	info := <-e.DeviceChannel()
+	if !info.Clock || info.ClockLeftMs != 300000 || info.ClockRightMs != 90000 || info.ClockRunning != "left" {
+		t.Errorf("expected the times of the clock, got %+v", info)
+	}
+
+	// the times are published whenever they change
+	e.seen(ClockEvent{Left: 5 * time.Minute, Right: 89 * time.Second, Running: CLOCK_SIDE_NONE, Connected: true})
+	select {
+	case info := <-e.DeviceChannel():
+		if info.ClockRightMs != 89000 || info.ClockRunning != "" {
+			t.Errorf("expected the stopped clock, got %+v", info)
+		}
+	default:
+		t.Error("expected the changed times to be published")
+	}
+}
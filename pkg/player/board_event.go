@@ -0,0 +1,51 @@
+package player
+
+import (
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// BoardEvent is something a board reported, translated by its driver
+type BoardEvent interface{}
+
+// PositionEvent reports the pieces on all squares of the board in normal orientation
+type PositionEvent struct {
+	Pieces [64]chess.Piece
+}
+
+// SquareEvent reports a piece placed on or lifted from a square of the board in normal orientation
+type SquareEvent struct {
+	Square chess.Square
+	Piece  chess.Piece
+}
+
+// DeviceEvent reports information about the board. Empty fields were not reported.
+type DeviceEvent struct {
+	SerialNumber string
+	Trademark    string
+	Version      string
+}
+
+// BatteryEvent reports the remaining battery capacity in percent or -1 if it is unknown
+type BatteryEvent struct {
+	Percent int
+}
+
+// ClockEvent reports the times of a clock connected to the board
+type ClockEvent struct {
+	Left  time.Duration
+	Right time.Duration
+	// Running is the side whose time is running or CLOCK_SIDE_NONE
+	Running ClockSide
+	// Connected is false if no clock is connected to the board
+	Connected bool
+}
+
+// ButtonEvent reports a button pressed on a clock connected to the board
+type ButtonEvent struct {
+	Button ClockButton
+}
+
+// AliveEvent is reported for everything else the board sends, it only shows that the board is connected
+type AliveEvent struct{}
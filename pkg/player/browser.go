@@ -0,0 +1,110 @@
+package player
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/notnil/chess"
+)
+
+var ErrNotYourTurn = errors.New("it is not your turn")
+
+// PLAYER_TYPE_BROWSER is the type of browser players saved with the game
+const PLAYER_TYPE_BROWSER = "browser"
+
+// Browser is a human player entering moves in the web UI instead of on the board
+type Browser struct {
+	name  string
+	moves chan browserMove
+}
+
+type browserMove struct {
+	move  string
+	reply chan error
+}
+
+func NewBrowserPlayer(name string) *Browser {
+	log.Printf("Browser player created with name %s", name)
+	return &Browser{
+		name:  name,
+		moves: make(chan browserMove),
+	}
+}
+
+func (p *Browser) IsBot() bool {
+	return false
+}
+
+// Type is saved with the game to play in the browser again when the game is resumed
+func (p *Browser) Type() string {
+	return PLAYER_TYPE_BROWSER
+}
+
+func (p *Browser) Name() string {
+	return p.name
+}
+
+func (p *Browser) SetColor(color chess.Color) {
+
+}
+
+// MakeMove waits until a legal move was entered in UCI notation, e.g. "e2e4"
+func (p *Browser) MakeMove(ctx context.Context, game *chess.Game) (*chess.Move, error) {
+	for {
+		select {
+		case m := <-p.moves:
+			move, err := p.decode(game, m.move)
+			m.reply <- err
+			if err == nil {
+				log.Printf("Browser player %s moved\n", p.name)
+				return move, nil
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (p *Browser) decode(game *chess.Game, s string) (*chess.Move, error) {
+	move, err := chess.UCINotation{}.Decode(game.Position(), s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid move %s", s)
+	}
+
+	for _, valid := range game.ValidMoves() {
+		if valid.String() == move.String() {
+			return valid, nil
+		}
+	}
+
+	// in Chess960 castling is encoded as the king moving to the rook, but the king may be dragged to its castled square
+	for _, valid := range game.ValidMoves() {
+		if (valid.HasTag(chess.KingSideCastle) || valid.HasTag(chess.QueenSideCastle)) && valid.S1() == move.S1() &&
+			game.Position().Update(valid).Board().Piece(move.S2()).Type() == chess.King {
+			return valid, nil
+		}
+	}
+
+	return nil, fmt.Errorf("illegal move %s", s)
+}
+
+// Move passes a move to the player. It fails if the player is not to move.
+func (p *Browser) Move(move string) error {
+	m := browserMove{
+		move:  move,
+		reply: make(chan error, 1),
+	}
+
+	select {
+	case p.moves <- m:
+		return <-m.reply
+	default:
+		return ErrNotYourTurn
+	}
+}
+
+func (p *Browser) End() {
+
+}
@@ -0,0 +1,24 @@
+package player
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+)
+
+func TestBrowserDecodeChess960Castling(t *testing.T) {
+	fen, _ := chess.FEN("1r4kr/pppppppp/8/8/8/8/PPPPPPPP/1R4KR w HBhb - 0 1")
+	g := chess.NewGame(fen)
+	p := NewBrowserPlayer("test")
+
+	for _, s := range []string{"g1b1", "g1c1"} {
+		move, err := p.decode(g, s)
+		if err != nil || !move.HasTag(chess.QueenSideCastle) {
+			t.Errorf("%s: expected queen side castling, got %v %v", s, move, err)
+		}
+	}
+
+	if _, err := p.decode(g, "g1d1"); err == nil {
+		t.Error("g1d1 must not be decoded as castling")
+	}
+}
@@ -0,0 +1,186 @@
+package player
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+	"github.com/windler/chesspal/pkg/dgt"
+)
+
+// captureReader returns the bytes read from the board in a capture one entry per read and keeps the time of the entry
+type captureReader struct {
+	entries []dgt.CaptureEntry
+	now     time.Duration
+}
+
+func (r *captureReader) Read(b []byte) (int, error) {
+	for len(r.entries) > 0 && r.entries[0].Written {
+		r.entries = r.entries[1:]
+	}
+	if len(r.entries) == 0 {
+		return 0, io.EOF
+	}
+
+	entry := &r.entries[0]
+	r.now = entry.Time
+	n := copy(b, entry.Data)
+	entry.Data = entry.Data[n:]
+	if len(entry.Data) == 0 {
+		r.entries = r.entries[1:]
+	}
+	return n, nil
+}
+
+// replayTimer is a timer of the move detector started by a replayClock
+type replayTimer struct {
+	at      time.Duration
+	f       func()
+	stopped bool
+}
+
+// replayClock runs the timers of the move detector by the times of a capture instead of waiting for them
+type replayClock struct {
+	now    time.Duration
+	timers []*replayTimer
+}
+
+func (c *replayClock) afterFunc(d time.Duration, f func()) func() bool {
+	timer := &replayTimer{at: c.now + d, f: f}
+	c.timers = append(c.timers, timer)
+
+	return func() bool {
+		stopped := timer.stopped
+		timer.stopped = true
+		return !stopped
+	}
+}
+
+// fire runs the earliest timer due until the given time and returns false if no timer is due
+func (c *replayClock) fire(until time.Duration) bool {
+	var next *replayTimer
+	for _, timer := range c.timers {
+		if !timer.stopped && timer.at <= until && (next == nil || timer.at < next.at) {
+			next = timer
+		}
+	}
+	if next == nil {
+		c.now = until
+		return false
+	}
+
+	c.now = next.at
+	next.stopped = true
+	next.f()
+	return true
+}
+
+// captureMoves replays a capture through the decoder of the driver and the move detection of the engine and returns
+// the moves made in the game, which is updated with them. The detector runs on the times of the capture, so the
+// capture is replayed at once. Promotions without the piece placed on the board are not detected.
+func captureMoves(t *testing.T, entries []dgt.CaptureEntry, driver BoardDriver, game *chess.Game, settle time.Duration) []*chess.Move {
+	t.Helper()
+	e := NewBoardEngine()
+	e.SetDriver(driver)
+	e.SetSettleTime(settle)
+	clock := &replayClock{}
+	e.detector.afterFunc = clock.afterFunc
+	go func() {
+		for range e.PostionChannel() {
+		}
+	}()
+
+	moves := []*chess.Move{}
+	// wait lets the engine wait for the next move like MakeMove does
+	wait := func() {
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+
+		e.game = game
+		e.moved = make(chan *chess.Move, 1)
+	}
+	// run fires the timers of the detector due until the given time and plays the detected moves
+	run := func(until time.Duration) {
+		for clock.fire(until) {
+			select {
+			case move := <-e.moved:
+				if err := game.Move(move); err != nil {
+					t.Fatal(err)
+				}
+				moves = append(moves, move)
+				wait()
+			default:
+			}
+		}
+	}
+
+	wait()
+	r := &captureReader{entries: append([]dgt.CaptureEntry(nil), entries...)}
+	decoder := driver.Decoder(r)
+	for {
+		event, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		run(r.now)
+		e.handle(event)
+	}
+
+	// the board stays as it is after the capture
+	run(clock.now + 2*settle*PARTIAL_MOVE_FACTOR)
+	return moves
+}
+
+// readCapture reads a capture of the testdata folder
+func readCapture(t *testing.T, name string) []dgt.CaptureEntry {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	entries, err := dgt.ReadCapture(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestCaptureMoves(t *testing.T) {
+	// the capture has two sessions, the board was restarted before castling
+	entries := readCapture(t, "ruy_lopez.capture")
+	game := chess.NewGame()
+
+	moves := captureMoves(t, entries, USBDriver{}, game, DEFAULT_SETTLE_TIME)
+
+	expected := "e2e4 e7e5 g1f3 b8c6 f1b5 a7a6 b5c6 d7c6 e1g1 c8g4 h2h3 g4f3 d1f3"
+	actual := []string{}
+	for _, move := range moves {
+		actual = append(actual, move.String())
+	}
+	if strings.Join(actual, " ") != expected {
+		t.Errorf("expected %s, got %s", expected, strings.Join(actual, " "))
+	}
+
+	if fen := game.Position().String(); fen != "r2qkbnr/1pp2ppp/p1p5/4p3/4P3/5Q1P/PPPP1PP1/RNB2RK1 b kq - 0 7" {
+		t.Errorf("unexpected position %s", fen)
+	}
+}
+
+func TestCaptureMovesSettleTime(t *testing.T) {
+	entries := readCapture(t, "ruy_lopez.capture")
+
+	// with a short settle time the pawn slid over e3 is detected as a move to e3
+	moves := captureMoves(t, entries, USBDriver{}, chess.NewGame(), DEFAULT_SETTLE_TIME/4)
+	if len(moves) == 0 || moves[0].String() != "e2e3" {
+		t.Errorf("expected e2e3 to be detected first, got %v", moves)
+	}
+}
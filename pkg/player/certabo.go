@@ -0,0 +1,213 @@
+package player
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/jacobsa/go-serial/serial"
+	"github.com/notnil/chess"
+	"github.com/windler/chesspal/pkg/dgt"
+)
+
+// CERTABO_RFID_SIZE is the number of bytes of the RFID of a piece
+const CERTABO_RFID_SIZE = 5
+
+// CertaboDriver drives Certabo boards. The board reports the RFIDs of the pieces on its squares, which are learned
+// whenever the board shows the start position. Unknown pieces placed on the last rank, e.g. the spare queens, are
+// learned as queens.
+type CertaboDriver struct {
+	mutex      *sync.Mutex
+	upsideDown bool
+	pieces     map[string]chess.Piece
+}
+
+func NewCertaboDriver() *CertaboDriver {
+	return &CertaboDriver{
+		mutex:  &sync.Mutex{},
+		pieces: map[string]chess.Piece{},
+	}
+}
+
+func (d *CertaboDriver) Name() string {
+	return "Certabo"
+}
+
+func (d *CertaboDriver) Open(port string) (io.ReadWriteCloser, error) {
+	return serial.Open(serial.OpenOptions{
+		PortName:        port,
+		BaudRate:        38400,
+		DataBits:        8,
+		StopBits:        1,
+		MinimumReadSize: 1,
+	})
+}
+
+func (d *CertaboDriver) Simulate(board *dgt.Simulator) io.ReadWriteCloser {
+	return NewCertaboSimulator(board).Connect()
+}
+
+// SetUpsideDown tells the driver on which side white starts, which is needed to learn the pieces
+func (d *CertaboDriver) SetUpsideDown(upsideDown bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.upsideDown = upsideDown
+}
+
+func (d *CertaboDriver) Decoder(r io.Reader) EventDecoder {
+	return &certaboDecoder{
+		driver:  d,
+		scanner: bufio.NewScanner(r),
+	}
+}
+
+func (d *CertaboDriver) Init() [][]byte {
+	return [][]byte{d.LEDs()}
+}
+
+func (d *CertaboDriver) Query() [][]byte {
+	return nil
+}
+
+func (d *CertaboDriver) Poll() []byte {
+	// the board sends its squares all the time
+	return nil
+}
+
+// LEDs lights the LEDs of the given squares. The LEDs are set by one byte per rank from the 8th rank to the 1st with a
+// bit per file.
+func (d *CertaboDriver) LEDs(squares ...chess.Square) []byte {
+	leds := make([]byte, 8)
+	for _, sq := range squares {
+		leds[7-int(sq.Rank())] |= 1 << sq.File()
+	}
+	return leds
+}
+
+// position translates the RFIDs of the squares, which are sent from a8 to h1, into the pieces. The mutex must be held
+// by the caller.
+func (d *CertaboDriver) position(rfids [64]string) PositionEvent {
+	if isStartPosition(rfids) {
+		d.learn(rfids)
+	}
+
+	// white promotes on the far side of the board
+	whiteRow, blackRow := 0, 7
+	if d.upsideDown {
+		whiteRow, blackRow = blackRow, whiteRow
+	}
+
+	event := PositionEvent{}
+	for i, rfid := range rfids {
+		if rfid == "" {
+			continue
+		}
+
+		piece, known := d.pieces[rfid]
+		switch {
+		case known:
+		case i/8 == whiteRow:
+			piece = chess.WhiteQueen
+			d.pieces[rfid] = piece
+		case i/8 == blackRow:
+			piece = chess.BlackQueen
+			d.pieces[rfid] = piece
+		}
+		event.Pieces[a8Square(i)] = piece
+	}
+
+	return event
+}
+
+// learn assigns the pieces of the start position to the RFIDs. The mutex must be held by the caller.
+func (d *CertaboDriver) learn(rfids [64]string) {
+	start := chess.NewGame().Position().Board()
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		if piece := start.Piece(sq); piece != chess.NoPiece {
+			d.pieces[rfids[a8Index(boardSquare(sq, d.upsideDown))]] = piece
+		}
+	}
+}
+
+// isStartPosition returns true if pieces are on the first and last two ranks only
+func isStartPosition(rfids [64]string) bool {
+	for i, rfid := range rfids {
+		row := i / 8
+		occupied := row < 2 || row > 5
+		if (rfid != "") != occupied {
+			return false
+		}
+	}
+	return true
+}
+
+// certaboDecoder translates the lines sent by a Certabo board into board events. Each line holds the bytes of the
+// RFIDs of all squares as decimal numbers. Only changed positions are reported.
+type certaboDecoder struct {
+	driver  *CertaboDriver
+	scanner *bufio.Scanner
+	last    PositionEvent
+	read    bool
+}
+
+func (d *certaboDecoder) Next() (BoardEvent, error) {
+	if !d.scanner.Scan() {
+		if err := d.scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+
+	rfids, err := parseCertaboLine(d.scanner.Text())
+	if err != nil {
+		// incomplete lines are sent while the board starts
+		return AliveEvent{}, nil
+	}
+
+	d.driver.mutex.Lock()
+	position := d.driver.position(rfids)
+	d.driver.mutex.Unlock()
+
+	if d.read && position == d.last {
+		return AliveEvent{}, nil
+	}
+	d.last = position
+	d.read = true
+
+	return position, nil
+}
+
+func parseCertaboLine(line string) ([64]string, error) {
+	rfids := [64]string{}
+
+	numbers := strings.FieldsFunc(line, func(r rune) bool {
+		return !unicode.IsDigit(r)
+	})
+	if len(numbers) != 64*CERTABO_RFID_SIZE {
+		return rfids, fmt.Errorf("expected %d numbers, got %d", 64*CERTABO_RFID_SIZE, len(numbers))
+	}
+
+	for field := range rfids {
+		rfid := make([]byte, CERTABO_RFID_SIZE)
+		empty := true
+		for i := range rfid {
+			n, err := strconv.Atoi(numbers[field*CERTABO_RFID_SIZE+i])
+			if err != nil || n > 255 {
+				return rfids, fmt.Errorf("invalid number %s", numbers[field*CERTABO_RFID_SIZE+i])
+			}
+			rfid[i] = byte(n)
+			empty = empty && n == 0
+		}
+
+		if !empty {
+			rfids[field] = fmt.Sprintf("%x", rfid)
+		}
+	}
+
+	return rfids, nil
+}
@@ -0,0 +1,109 @@
+package player
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/notnil/chess"
+	"github.com/windler/chesspal/pkg/dgt"
+)
+
+// CERTABO_SIMULATOR_INTERVAL is the interval the simulated Certabo board sends its squares
+const CERTABO_SIMULATOR_INTERVAL = 50 * time.Millisecond
+
+// CertaboSimulator sends the pieces of a simulated board the way a Certabo board does. Every kind of piece has one
+// RFID, which is enough to learn the pieces from the start position.
+type CertaboSimulator struct {
+	board *dgt.Simulator
+	mutex *sync.Mutex
+	conn  net.Conn
+	leds  []byte
+}
+
+// NewCertaboSimulator creates a Certabo board showing the pieces of the simulated board
+func NewCertaboSimulator(board *dgt.Simulator) *CertaboSimulator {
+	return &CertaboSimulator{
+		board: board,
+		mutex: &sync.Mutex{},
+		leds:  make([]byte, 8),
+	}
+}
+
+// Connect returns the connection to the board. An existing connection is closed.
+func (s *CertaboSimulator) Connect() io.ReadWriteCloser {
+	board, driver := net.Pipe()
+
+	s.mutex.Lock()
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.conn = board
+	s.mutex.Unlock()
+
+	go s.writeLoop(board)
+	go s.readLoop(board)
+
+	return driver
+}
+
+func (s *CertaboSimulator) writeLoop(conn net.Conn) {
+	ticker := time.NewTicker(CERTABO_SIMULATOR_INTERVAL)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := conn.Write([]byte(s.line())); err != nil {
+			return
+		}
+	}
+}
+
+// readLoop reads the LED commands, which are one byte per rank
+func (s *CertaboSimulator) readLoop(conn net.Conn) {
+	for {
+		leds := make([]byte, 8)
+		if _, err := io.ReadFull(conn, leds); err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		s.leds = leds
+		s.mutex.Unlock()
+	}
+}
+
+// line returns the RFIDs of all squares from a8 to h1 as decimal numbers
+func (s *CertaboSimulator) line() string {
+	rfids := [64][CERTABO_RFID_SIZE]byte{}
+	board := s.board.Board()
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		if piece := board.Piece(sq); piece != chess.NoPiece {
+			rfids[a8Index(sq)] = [CERTABO_RFID_SIZE]byte{0x80, 0, 0, 0, byte(piece)}
+		}
+	}
+
+	numbers := make([]string, 0, 64*CERTABO_RFID_SIZE)
+	for _, rfid := range rfids {
+		for _, b := range rfid {
+			numbers = append(numbers, fmt.Sprint(b))
+		}
+	}
+	return strings.Join(numbers, " ") + "\r\n"
+}
+
+// LEDs returns the squares whose LEDs are lit in ascending order
+func (s *CertaboSimulator) LEDs() []chess.Square {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	squares := []chess.Square{}
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		if s.leds[7-int(sq.Rank())]&(1<<sq.File()) != 0 {
+			squares = append(squares, sq)
+		}
+	}
+	return squares
+}
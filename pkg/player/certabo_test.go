@@ -0,0 +1,33 @@
+package player
+
+import (
+	"testing"
+
+	"github.com/windler/chesspal/pkg/dgt"
+)
+
+func TestCertaboDriver(t *testing.T) {
+	sim := dgt.NewSimulator()
+	e := NewBoardEngine()
+	connectSimulator(e, NewCertaboDriver(), sim)
+
+	info := waitForDevice(t, e, func(info DeviceInfo) bool {
+		return info.Connected
+	})
+	if info.Type != "Certabo" {
+		t.Errorf("expected a Certabo board, got %+v", info)
+	}
+
+	assertLegalsMate(t, playOnBoard(t, e, sim.Move, LEGALS_MATE))
+}
+
+func TestCertaboLEDs(t *testing.T) {
+	sim := NewCertaboSimulator(dgt.NewSimulator())
+	e := NewBoardEngine()
+	connectSimulator(e, simulatedBy{NewCertaboDriver(), sim.Connect}, dgt.NewSimulator())
+
+	waitForDevice(t, e, func(info DeviceInfo) bool {
+		return info.Connected
+	})
+	showMove(t, e, sim)
+}
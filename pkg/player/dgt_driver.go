@@ -0,0 +1,212 @@
+package player
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jacobsa/go-serial/serial"
+	"github.com/notnil/chess"
+	"github.com/windler/chesspal/pkg/dgt"
+)
+
+// USBDriver drives DGT e-Boards connected by USB
+type USBDriver struct{}
+
+func (d USBDriver) Name() string {
+	return "DGT e-Board"
+}
+
+func (d USBDriver) Open(port string) (io.ReadWriteCloser, error) {
+	return openSerial(port)
+}
+
+func (d USBDriver) Decoder(r io.Reader) EventDecoder {
+	return newDGTDecoder(r)
+}
+
+func (d USBDriver) Init() [][]byte {
+	return [][]byte{
+		{dgt.DGT_SEND_RESET},
+		{dgt.DGT_SEND_BRD},
+		// updates include the times and buttons of a clock connected to the board
+		{dgt.DGT_SEND_UPDATE},
+	}
+}
+
+func (d USBDriver) Query() [][]byte {
+	return dgtQuery()
+}
+
+func (d USBDriver) Poll() []byte {
+	return []byte{dgt.DGT_SEND_VERSION}
+}
+
+func (d USBDriver) LEDs(squares ...chess.Square) []byte {
+	return nil
+}
+
+// SetClock sets the times of a DGT 3000 connected to the board
+func (d USBDriver) SetClock(left, right time.Duration, running ClockSide) []byte {
+	return dgt.ClockSetAndRun(left, right, dgtClockSides[running])
+}
+
+func (d USBDriver) ClockText(text string, beep bool) []byte {
+	return dgt.ClockText(text, beep)
+}
+
+func (d USBDriver) ClockEnd() []byte {
+	return dgt.ClockEnd()
+}
+
+// PegasusDriver drives DGT Pegasus boards. The Pegasus only has Bluetooth LE, so it is reached through the serial
+// port provided by a BLE serial bridge.
+type PegasusDriver struct{}
+
+func (d PegasusDriver) Name() string {
+	return "DGT Pegasus"
+}
+
+func (d PegasusDriver) Open(port string) (io.ReadWriteCloser, error) {
+	return openSerial(port)
+}
+
+func (d PegasusDriver) Decoder(r io.Reader) EventDecoder {
+	return newDGTDecoder(r)
+}
+
+func (d PegasusDriver) Init() [][]byte {
+	return [][]byte{
+		{dgt.DGT_SEND_RESET},
+		{dgt.DGT_SEND_BRD},
+		// the Pegasus has no clock, so plain board updates are requested
+		{dgt.DGT_SEND_UPDATE_NICE},
+		dgt.PegasusLEDsOff(),
+	}
+}
+
+func (d PegasusDriver) Query() [][]byte {
+	return dgtQuery()
+}
+
+func (d PegasusDriver) Poll() []byte {
+	return []byte{dgt.DGT_SEND_VERSION}
+}
+
+func (d PegasusDriver) LEDs(squares ...chess.Square) []byte {
+	fields := []byte{}
+	for _, sq := range squares {
+		fields = append(fields, dgt.Field(sq))
+	}
+	return dgt.PegasusLEDs(fields...)
+}
+
+// dgtQuery asks the board for its serial number, trademark, version and battery status
+func dgtQuery() [][]byte {
+	return [][]byte{
+		{dgt.DGT_RETURN_LONG_SERIALNR},
+		{dgt.DGT_RETURN_SERIALNR},
+		{dgt.DGT_SEND_TRADEMARK},
+		{dgt.DGT_SEND_VERSION},
+		{dgt.DGT_SEND_BATTERY_STATUS},
+	}
+}
+
+func openSerial(port string) (io.ReadWriteCloser, error) {
+	options := serial.OpenOptions{
+		PortName:        port,
+		BaudRate:        9600,
+		DataBits:        8,
+		StopBits:        1,
+		MinimumReadSize: 1,
+	}
+
+	return serial.Open(options)
+}
+
+var dgtClockSides = map[ClockSide]dgt.ClockSide{
+	CLOCK_SIDE_NONE:  dgt.CLOCK_SIDE_NONE,
+	CLOCK_SIDE_LEFT:  dgt.CLOCK_SIDE_LEFT,
+	CLOCK_SIDE_RIGHT: dgt.CLOCK_SIDE_RIGHT,
+}
+
+var dgtClockButtons = map[dgt.ClockButton]ClockButton{
+	dgt.CLOCK_BUTTON_BACK:  CLOCK_BUTTON_BACK,
+	dgt.CLOCK_BUTTON_MINUS: CLOCK_BUTTON_MINUS,
+	dgt.CLOCK_BUTTON_PLAY:  CLOCK_BUTTON_PLAY,
+	dgt.CLOCK_BUTTON_PLUS:  CLOCK_BUTTON_PLUS,
+	dgt.CLOCK_BUTTON_NEXT:  CLOCK_BUTTON_NEXT,
+}
+
+// dgtDecoder translates the messages of DGT boards into board events
+type dgtDecoder struct {
+	decoder *dgt.Decoder
+	// longSerial is true once the board sent its long serial number, which is preferred to the short one
+	longSerial bool
+}
+
+func newDGTDecoder(r io.Reader) *dgtDecoder {
+	return &dgtDecoder{
+		decoder: dgt.NewDecoder(r),
+	}
+}
+
+func (d *dgtDecoder) Next() (BoardEvent, error) {
+	msg, err := d.decoder.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.event(msg), nil
+}
+
+func (d *dgtDecoder) event(msg dgt.Message) BoardEvent {
+	switch m := msg.(type) {
+	case dgt.BoardDump:
+		event := PositionEvent{}
+		for field, code := range m.Squares {
+			event.Pieces[dgt.Square(byte(field))] = dgt.Piece(code)
+		}
+		return event
+	case dgt.FieldUpdate:
+		if m.Field < 64 {
+			return SquareEvent{Square: dgt.Square(m.Field), Piece: dgt.Piece(m.Piece)}
+		}
+	case dgt.LongSerialNumber:
+		d.longSerial = true
+		return DeviceEvent{SerialNumber: m.Serial}
+	case dgt.SerialNumber:
+		if !d.longSerial {
+			return DeviceEvent{SerialNumber: m.Serial}
+		}
+	case dgt.Trademark:
+		return DeviceEvent{Trademark: m.Text}
+	case dgt.Version:
+		return DeviceEvent{Version: fmt.Sprintf("%d.%d", m.Major, m.Minor)}
+	case dgt.BatteryStatus:
+		return BatteryEvent{Percent: m.Percent()}
+	case dgt.ClockTime:
+		return clockEvent(m)
+	}
+
+	return AliveEvent{}
+}
+
+// clockEvent translates the times or the pressed button of a DGT 3000
+func clockEvent(clock dgt.ClockTime) BoardEvent {
+	if button, ok := dgtClockButtons[clock.Button()]; ok {
+		return ButtonEvent{Button: button}
+	}
+	if clock.IsAck() {
+		return AliveEvent{}
+	}
+
+	state := clock.State()
+	event := ClockEvent{Left: state.Left, Right: state.Right, Running: CLOCK_SIDE_NONE, Connected: state.Connected}
+	for side, dgtSide := range dgtClockSides {
+		if dgtSide == state.Running {
+			event.Running = side
+		}
+	}
+	return event
+}
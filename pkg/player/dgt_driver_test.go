@@ -0,0 +1,175 @@
+package player
+
+import (
+	"io"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+	"github.com/windler/chesspal/pkg/dgt"
+)
+
+// waitForDevice waits until the device info of the engine matches
+func waitForDevice(t *testing.T, e *BoardEngine, match func(DeviceInfo) bool) DeviceInfo {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if info := e.DeviceInfo(); match(info) {
+			return info
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("unexpected device info %+v", e.DeviceInfo())
+	return DeviceInfo{}
+}
+
+// ledBoard is a simulated board with LEDs. The lit squares are returned in ascending order.
+type ledBoard interface {
+	LEDs() []chess.Square
+}
+
+// dgtLEDs are the LEDs of a simulated DGT board
+type dgtLEDs struct {
+	*dgt.Simulator
+}
+
+func (s dgtLEDs) LEDs() []chess.Square {
+	squares := []chess.Square{}
+	for _, field := range s.Simulator.LEDs() {
+		squares = append(squares, dgt.Square(field))
+	}
+	sort.Slice(squares, func(i, j int) bool {
+		return squares[i] < squares[j]
+	})
+	return squares
+}
+
+// simulatedBy simulates the board of a driver by the given connection
+type simulatedBy struct {
+	BoardDriver
+	connect func() io.ReadWriteCloser
+}
+
+func (d simulatedBy) Simulate(board *dgt.Simulator) io.ReadWriteCloser {
+	return d.connect()
+}
+
+// waitForLEDs fails unless the simulated board lights the LEDs of the given squares in time
+func waitForLEDs(t *testing.T, sim ledBoard, squares ...chess.Square) {
+	t.Helper()
+	expected := append([]chess.Square{}, squares...)
+	sort.Slice(expected, func(i, j int) bool {
+		return expected[i] < expected[j]
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if reflect.DeepEqual(sim.LEDs(), expected) {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected the LEDs %v, got %v", expected, sim.LEDs())
+}
+
+// queried is true once the board answered the device query
+func queried(info DeviceInfo) bool {
+	return info.Connected && info.SerialNumber != "" && info.Trademark != "" && info.Version != ""
+}
+
+func TestUSBDriver(t *testing.T) {
+	sim := dgt.NewSimulator()
+	e := NewBoardEngine()
+	connectSimulator(e, USBDriver{}, sim)
+
+	info := waitForDevice(t, e, queried)
+	expected := DeviceInfo{Type: "DGT e-Board", Port: dgt.SIMULATOR_PORT, Connected: true, SerialNumber: "SIM0000000", Trademark: "Virtual DGT board", Version: "1.0", Battery: -1}
+	info.LastSeen = time.Time{}
+	if info != expected {
+		t.Errorf("expected the device %+v, got %+v", expected, info)
+	}
+
+	// the e-Board has no LEDs
+	if err := e.ShowMove(&chess.Move{}); err != nil {
+		t.Error(err)
+	}
+	if leds := sim.LEDs(); len(leds) != 0 {
+		t.Errorf("expected no LEDs, got %v", leds)
+	}
+
+	assertLegalsMate(t, playOnBoard(t, e, sim.Move, LEGALS_MATE))
+}
+
+func TestPegasusDriver(t *testing.T) {
+	sim := dgt.NewSimulator()
+	driver := PegasusDriver{}
+	if _, ok := BoardDriver(driver).(ClockDriver); ok {
+		t.Error("the Pegasus has no clock")
+	}
+	e := NewBoardEngine()
+	connectSimulator(e, driver, sim)
+
+	info := waitForDevice(t, e, queried)
+	if info.Type != "DGT Pegasus" || info.Port != dgt.SIMULATOR_PORT {
+		t.Errorf("expected a Pegasus on the simulator, got %+v", info)
+	}
+
+	// the LEDs are turned off when the board is opened
+	waitForLEDs(t, dgtLEDs{sim})
+
+	showMove(t, e, dgtLEDs{sim})
+
+	assertLegalsMate(t, playOnBoard(t, e, sim.Move, LEGALS_MATE))
+}
+
+// showMove fails unless the LEDs of the squares of a move are lit and turned off again
+func showMove(t *testing.T, e *BoardEngine, sim ledBoard) {
+	t.Helper()
+	move, err := chess.UCINotation{}.Decode(chess.StartingPosition(), "g1f3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.ShowMove(move); err != nil {
+		t.Fatal(err)
+	}
+	waitForLEDs(t, sim, chess.G1, chess.F3)
+
+	if err := e.ClearLEDs(); err != nil {
+		t.Fatal(err)
+	}
+	waitForLEDs(t, sim)
+}
+
+func TestDGTDecoderEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []dgt.Message
+		expected BoardEvent
+	}{
+		{name: "field update", messages: []dgt.Message{dgt.FieldUpdate{Field: dgt.Field(chess.E4), Piece: dgt.DGT_WPAWN}}, expected: SquareEvent{Square: chess.E4, Piece: chess.WhitePawn}},
+		{name: "field out of the board", messages: []dgt.Message{dgt.FieldUpdate{Field: 64, Piece: dgt.DGT_WPAWN}}, expected: AliveEvent{}},
+		{name: "long serial number", messages: []dgt.Message{dgt.LongSerialNumber{Serial: "12345"}}, expected: DeviceEvent{SerialNumber: "12345"}},
+		{name: "short serial number", messages: []dgt.Message{dgt.SerialNumber{Serial: "123"}}, expected: DeviceEvent{SerialNumber: "123"}},
+		{name: "short after long serial number", messages: []dgt.Message{dgt.LongSerialNumber{Serial: "12345"}, dgt.SerialNumber{Serial: "123"}}, expected: AliveEvent{}},
+		{name: "version", messages: []dgt.Message{dgt.Version{Major: 3, Minor: 1}}, expected: DeviceEvent{Version: "3.1"}},
+		{name: "battery", messages: []dgt.Message{dgt.BatteryStatus{Data: []byte{80}}}, expected: BatteryEvent{Percent: 80}},
+		{name: "clock times", messages: []dgt.Message{dgt.ClockTime{Data: [7]byte{0x01, 0x30, 0x00, 0x00, 0x05, 0x09, 0x03}}},
+			expected: ClockEvent{Left: 5*time.Minute + 9*time.Second, Right: 90 * time.Minute, Running: CLOCK_SIDE_RIGHT, Connected: true}},
+		{name: "no clock", messages: []dgt.Message{dgt.ClockTime{Data: [7]byte{6: 0x20}}}, expected: ClockEvent{Running: CLOCK_SIDE_NONE}},
+		{name: "button", messages: []dgt.Message{dgt.ClockTime{Data: [7]byte{0x0a, 0, 0x08, 0x2a, 0, 0x35, 0}}}, expected: ButtonEvent{Button: CLOCK_BUTTON_NEXT}},
+		{name: "acknowledgement", messages: []dgt.Message{dgt.ClockTime{Data: [7]byte{0x0a, 0, 0x0a, 0x2a, 0, 0x0a, 0}}}, expected: AliveEvent{}},
+	}
+
+	for _, test := range tests {
+		decoder := newDGTDecoder(nil)
+		var event BoardEvent
+		for _, msg := range test.messages {
+			event = decoder.event(msg)
+		}
+		if !reflect.DeepEqual(event, test.expected) {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, event)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package player
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jacobsa/go-serial/serial"
+	"github.com/notnil/chess"
+	"github.com/windler/chesspal/pkg/dgt"
+)
+
+// MILLENNIUM_SCAN_INTERVAL is the interval a Millennium ChessLink is asked for its status
+const MILLENNIUM_SCAN_INTERVAL = 100 * time.Millisecond
+
+// MILLENNIUM_LEDS is the number of LEDs of a Millennium board. They are placed in a 9x9 grid at the corners of the
+// squares, starting at the corner of a8.
+const MILLENNIUM_LEDS = 81
+
+// MILLENNIUM_LED_ON is the blink pattern of a LED that is always on
+const MILLENNIUM_LED_ON = 0xff
+
+// millenniumSizes are the sizes of the replies of the board including the checksum
+var millenniumSizes = map[byte]int{
+	's': 1 + 64 + 2,
+	'v': 1 + 4 + 2,
+	'x': 1 + 2,
+	'l': 1 + 2,
+	'w': 1 + 4 + 2,
+	'r': 1 + 4 + 2,
+}
+
+// MILLENNIUM_READ_SIZE is the number of bytes read from the board at once
+const MILLENNIUM_READ_SIZE = 1024
+
+var millenniumPieces = map[byte]chess.Piece{
+	'P': chess.WhitePawn,
+	'R': chess.WhiteRook,
+	'N': chess.WhiteKnight,
+	'B': chess.WhiteBishop,
+	'Q': chess.WhiteQueen,
+	'K': chess.WhiteKing,
+	'p': chess.BlackPawn,
+	'r': chess.BlackRook,
+	'n': chess.BlackKnight,
+	'b': chess.BlackBishop,
+	'q': chess.BlackQueen,
+	'k': chess.BlackKing,
+}
+
+// MillenniumDriver drives Millennium ChessLink boards. The board does not report changes on its own, so its status is
+// requested every MILLENNIUM_SCAN_INTERVAL.
+type MillenniumDriver struct{}
+
+func (d MillenniumDriver) Name() string {
+	return "Millennium ChessLink"
+}
+
+func (d MillenniumDriver) Open(port string) (io.ReadWriteCloser, error) {
+	conn, err := serial.Open(serial.OpenOptions{
+		PortName:        port,
+		BaudRate:        38400,
+		DataBits:        7,
+		ParityMode:      serial.PARITY_ODD,
+		StopBits:        1,
+		MinimumReadSize: 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newPolledConn(conn, millenniumCommand("S"), MILLENNIUM_SCAN_INTERVAL), nil
+}
+
+func (d MillenniumDriver) Simulate(board *dgt.Simulator) io.ReadWriteCloser {
+	return newPolledConn(NewMillenniumSimulator(board).Connect(), millenniumCommand("S"), MILLENNIUM_SCAN_INTERVAL)
+}
+
+func (d MillenniumDriver) Decoder(r io.Reader) EventDecoder {
+	return &millenniumDecoder{
+		r:     r,
+		buf:   []byte{},
+		chunk: make([]byte, MILLENNIUM_READ_SIZE),
+	}
+}
+
+func (d MillenniumDriver) Init() [][]byte {
+	return [][]byte{
+		millenniumCommand("X"),
+		millenniumCommand("S"),
+	}
+}
+
+func (d MillenniumDriver) Query() [][]byte {
+	return [][]byte{millenniumCommand("V")}
+}
+
+func (d MillenniumDriver) Poll() []byte {
+	// the status is requested all the time anyway
+	return nil
+}
+
+func (d MillenniumDriver) LEDs(squares ...chess.Square) []byte {
+	if len(squares) == 0 {
+		return millenniumCommand("X")
+	}
+
+	leds := make([]byte, MILLENNIUM_LEDS)
+	for _, sq := range squares {
+		row, col := 7-int(sq.Rank()), int(sq.File())
+		for _, corner := range []int{row*9 + col, row*9 + col + 1, (row+1)*9 + col, (row+1)*9 + col + 1} {
+			leds[corner] = MILLENNIUM_LED_ON
+		}
+	}
+
+	return millenniumCommand("L00" + strings.ToUpper(hex.EncodeToString(leds)))
+}
+
+// millenniumCommand appends the checksum to a command, which is the XOR of all characters in hex
+func millenniumCommand(cmd string) []byte {
+	return []byte(cmd + fmt.Sprintf("%02X", millenniumChecksum([]byte(cmd))))
+}
+
+func millenniumChecksum(b []byte) byte {
+	var sum byte
+	for _, c := range b {
+		sum ^= c
+	}
+	return sum
+}
+
+// millenniumDecoder translates the replies of a Millennium board into board events. Only changed positions are
+// reported.
+type millenniumDecoder struct {
+	r     io.Reader
+	buf   []byte
+	chunk []byte
+	last  string
+}
+
+func (d *millenniumDecoder) Next() (BoardEvent, error) {
+	for {
+		if event := d.frame(); event != nil {
+			return event, nil
+		}
+
+		n, err := d.r.Read(d.chunk)
+		for _, c := range d.chunk[:n] {
+			// the parity bit is not part of the character
+			d.buf = append(d.buf, c&0x7f)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// frame removes the first complete reply from the buffer or returns nil if the buffer does not contain one
+func (d *millenniumDecoder) frame() BoardEvent {
+	for len(d.buf) > 0 {
+		size, known := millenniumSizes[d.buf[0]]
+		if !known {
+			d.buf = d.buf[1:]
+			continue
+		}
+		if len(d.buf) < size {
+			return nil
+		}
+
+		reply := d.buf[:size-2]
+		if fmt.Sprintf("%02X", millenniumChecksum(reply)) != strings.ToUpper(string(d.buf[size-2:size])) {
+			log.Printf("Skipped Millennium reply %q with invalid checksum", string(d.buf[:size]))
+			d.buf = d.buf[1:]
+			continue
+		}
+		d.buf = d.buf[size:]
+
+		return d.event(reply)
+	}
+
+	return nil
+}
+
+func (d *millenniumDecoder) event(reply []byte) BoardEvent {
+	switch reply[0] {
+	case 's':
+		status := string(reply[1:])
+		if status == d.last {
+			return AliveEvent{}
+		}
+		d.last = status
+
+		event := PositionEvent{}
+		for i, c := range reply[1:] {
+			event.Pieces[a8Square(i)] = millenniumPieces[c]
+		}
+		return event
+
+	case 'v':
+		version, err := hex.DecodeString(string(reply[1:]))
+		if err != nil {
+			return AliveEvent{}
+		}
+		return DeviceEvent{Version: fmt.Sprintf("%d.%d", version[0], version[1])}
+	}
+
+	return AliveEvent{}
+}
+
+// polledConn writes a command regularly to a connection until it is closed
+type polledConn struct {
+	io.ReadWriteCloser
+	mutex *sync.Mutex
+	done  chan struct{}
+	once  *sync.Once
+}
+
+func newPolledConn(conn io.ReadWriteCloser, cmd []byte, interval time.Duration) *polledConn {
+	c := &polledConn{
+		ReadWriteCloser: conn,
+		mutex:           &sync.Mutex{},
+		done:            make(chan struct{}),
+		once:            &sync.Once{},
+	}
+	go c.poll(cmd, interval)
+
+	return c
+}
+
+func (c *polledConn) poll(cmd []byte, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := c.Write(cmd); err != nil {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Write keeps the polled command from interrupting other commands
+func (c *polledConn) Write(b []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.ReadWriteCloser.Write(b)
+}
+
+func (c *polledConn) Close() error {
+	c.once.Do(func() {
+		close(c.done)
+	})
+	return c.ReadWriteCloser.Close()
+}
@@ -0,0 +1,162 @@
+package player
+
+import (
+	"bufio"
+	"encoding/hex"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/notnil/chess"
+	"github.com/windler/chesspal/pkg/dgt"
+)
+
+// millenniumCommandSizes are the sizes of the commands the simulator answers including the checksum
+var millenniumCommandSizes = map[byte]int{
+	'S': 1 + 2,
+	'V': 1 + 2,
+	'X': 1 + 2,
+	'L': 1 + 2 + 2*MILLENNIUM_LEDS + 2,
+}
+
+// MillenniumSimulator answers the commands of a Millennium ChessLink with the pieces of a simulated board
+type MillenniumSimulator struct {
+	board *dgt.Simulator
+	mutex *sync.Mutex
+	conn  net.Conn
+	leds  []byte
+}
+
+// NewMillenniumSimulator creates a Millennium ChessLink showing the pieces of the simulated board
+func NewMillenniumSimulator(board *dgt.Simulator) *MillenniumSimulator {
+	return &MillenniumSimulator{
+		board: board,
+		mutex: &sync.Mutex{},
+		leds:  make([]byte, MILLENNIUM_LEDS),
+	}
+}
+
+// Connect returns the connection to the board. An existing connection is closed.
+func (s *MillenniumSimulator) Connect() io.ReadWriteCloser {
+	board, driver := net.Pipe()
+
+	s.mutex.Lock()
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.conn = board
+	s.mutex.Unlock()
+
+	// the driver writes commands before it reads, so the replies are written in the background
+	out := make(chan []byte, 256)
+	go func() {
+		for reply := range out {
+			if _, err := board.Write(reply); err != nil {
+				return
+			}
+		}
+	}()
+	go s.readLoop(board, out)
+
+	return driver
+}
+
+func (s *MillenniumSimulator) readLoop(conn net.Conn, out chan []byte) {
+	defer close(out)
+
+	r := bufio.NewReader(conn)
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		size, known := millenniumCommandSizes[c&0x7f]
+		if !known {
+			log.Printf("Millennium simulator ignores %q", c&0x7f)
+			continue
+		}
+
+		cmd := make([]byte, size)
+		cmd[0] = c
+		if _, err := io.ReadFull(r, cmd[1:]); err != nil {
+			return
+		}
+		for i := range cmd {
+			cmd[i] &= 0x7f
+		}
+		if string(millenniumCommand(string(cmd[:size-2]))) != strings.ToUpper(string(cmd)) {
+			log.Printf("Millennium simulator skipped %q with invalid checksum", string(cmd))
+			continue
+		}
+
+		if reply := s.command(string(cmd[:size-2])); reply != nil {
+			out <- reply
+		}
+	}
+}
+
+func (s *MillenniumSimulator) command(cmd string) []byte {
+	switch cmd[0] {
+	case 'S':
+		return millenniumCommand("s" + s.status())
+	case 'V':
+		return millenniumCommand("v0100")
+	case 'X':
+		s.setLEDs(make([]byte, MILLENNIUM_LEDS))
+		return millenniumCommand("x")
+	case 'L':
+		// the LEDs follow the slot time
+		leds, err := hex.DecodeString(cmd[3:])
+		if err != nil {
+			return nil
+		}
+		s.setLEDs(leds)
+		return millenniumCommand("l")
+	}
+	return nil
+}
+
+// status returns the pieces of the squares as characters
+func (s *MillenniumSimulator) status() string {
+	chars := map[chess.Piece]byte{}
+	for c, piece := range millenniumPieces {
+		chars[piece] = c
+	}
+
+	board := s.board.Board()
+	status := []byte(strings.Repeat(".", 64))
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		if piece := board.Piece(sq); piece != chess.NoPiece {
+			status[a8Index(sq)] = chars[piece]
+		}
+	}
+	return string(status)
+}
+
+func (s *MillenniumSimulator) setLEDs(leds []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.leds = leds
+}
+
+// LEDs returns the squares whose four corner LEDs are lit in ascending order
+func (s *MillenniumSimulator) LEDs() []chess.Square {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	squares := []chess.Square{}
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		row, col := 7-int(sq.Rank()), int(sq.File())
+		lit := true
+		for _, corner := range []int{row*9 + col, row*9 + col + 1, (row+1)*9 + col, (row+1)*9 + col + 1} {
+			lit = lit && s.leds[corner] != 0
+		}
+		if lit {
+			squares = append(squares, sq)
+		}
+	}
+	return squares
+}
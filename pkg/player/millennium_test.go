@@ -0,0 +1,33 @@
+package player
+
+import (
+	"testing"
+
+	"github.com/windler/chesspal/pkg/dgt"
+)
+
+func TestMillenniumDriver(t *testing.T) {
+	sim := dgt.NewSimulator()
+	e := NewBoardEngine()
+	connectSimulator(e, MillenniumDriver{}, sim)
+
+	info := waitForDevice(t, e, func(info DeviceInfo) bool {
+		return info.Connected && info.Version != ""
+	})
+	if info.Type != "Millennium ChessLink" || info.Version != "1.0" {
+		t.Errorf("expected a Millennium ChessLink, got %+v", info)
+	}
+
+	assertLegalsMate(t, playOnBoard(t, e, sim.Move, LEGALS_MATE))
+}
+
+func TestMillenniumLEDs(t *testing.T) {
+	sim := NewMillenniumSimulator(dgt.NewSimulator())
+	e := NewBoardEngine()
+	connectSimulator(e, simulatedBy{MillenniumDriver{}, sim.Connect}, dgt.NewSimulator())
+
+	waitForDevice(t, e, func(info DeviceInfo) bool {
+		return info.Connected
+	})
+	showMove(t, e, sim)
+}
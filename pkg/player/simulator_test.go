@@ -0,0 +1,119 @@
+package player
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+	"github.com/windler/chesspal/pkg/dgt"
+	"github.com/windler/chesspal/pkg/game"
+)
+
+// lastGame keeps the game of the last update
+type lastGame struct {
+	mutex *sync.Mutex
+	game  chess.Game
+}
+
+func (l *lastGame) Render(g chess.Game, action game.UIAction) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.game = g
+}
+
+func (l *lastGame) get() chess.Game {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.game
+}
+
+// connectSimulator connects the engine to a simulated board speaking the protocol of the driver. Without simulator
+// the board is opened by the driver.
+func connectSimulator(e *BoardEngine, driver BoardDriver, sim *dgt.Simulator) {
+	e.SetDriver(driver)
+	if sim != nil {
+		e.Simulate(sim)
+	}
+	e.SetSettleTime(10 * time.Millisecond)
+
+	go func() {
+		for range e.PostionChannel() {
+		}
+	}()
+	go e.Connect(dgt.SIMULATOR_PORT, "")
+}
+
+// waitForMove waits until the engine waits for a move in the given position
+func waitForMove(t *testing.T, e *BoardEngine, pos *chess.Position) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		e.mutex.Lock()
+		waiting := e.game != nil && e.game.Position().String() == pos.String()
+		e.mutex.Unlock()
+		if waiting {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("the engine does not wait for a move in %s", pos)
+}
+
+// playOnBoard plays a PGN on a simulated board with both players using the engine and returns the finished game
+func playOnBoard(t *testing.T, e *BoardEngine, move func(*chess.Position, *chess.Move), pgn string) chess.Game {
+	t.Helper()
+	option, err := chess.PGN(strings.NewReader(pgn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := chess.NewGame(option)
+	// moves made before the board sends updates would be missed
+	waitForDevice(t, e, func(info DeviceInfo) bool {
+		return info.Connected
+	})
+
+	last := &lastGame{mutex: &sync.Mutex{}}
+	g := game.NewGame(NewBoardPlayer("black", e), NewBoardPlayer("white", e), last)
+	go g.Start("")
+
+	positions := expected.Positions()
+	for i, m := range expected.Moves() {
+		waitForMove(t, e, positions[i])
+		move(positions[i], m)
+	}
+
+	select {
+	case <-g.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the game to end")
+	}
+	return last.get()
+}
+
+// LEGALS_MATE has captures, a check and ends by checkmate
+const LEGALS_MATE = "1. e4 e5 2. Nf3 d6 3. Bc4 Bg4 4. Nc3 g6 5. Nxe5 Bxd1 6. Bxf7+ Ke7 7. Nd5# 1-0"
+
+const LEGALS_MATE_FEN = "rn1q1bnr/ppp1kB1p/3p2p1/3NN3/4P3/8/PPPP1PPP/R1BbK2R b KQ - 1 7"
+
+// assertLegalsMate fails unless the game ended with Legal's mate
+func assertLegalsMate(t *testing.T, played chess.Game) {
+	t.Helper()
+	if played.Position().String() != LEGALS_MATE_FEN {
+		t.Errorf("expected the position %s, got %s", LEGALS_MATE_FEN, played.Position())
+	}
+	if played.Outcome() != chess.WhiteWon || played.Method() != chess.Checkmate {
+		t.Errorf("expected white to win by checkmate, got %s by %s", played.Outcome(), played.Method())
+	}
+}
+
+func TestSimulatedGame(t *testing.T) {
+	sim := dgt.NewSimulator()
+	e := NewBoardEngine()
+	connectSimulator(e, USBDriver{}, sim)
+
+	assertLegalsMate(t, playOnBoard(t, e, sim.Move, LEGALS_MATE))
+}
@@ -1,29 +1,54 @@
 package player
 
 import (
+	"context"
+	"errors"
 	"log"
 	"time"
 
 	"github.com/notnil/chess"
 	"github.com/notnil/chess/uci"
+	"github.com/windler/chesspal/pkg/game"
 	"github.com/windler/chesspal/pkg/util"
 )
 
+// DEFAULT_ENGINE_TIMEOUT is added to the move time of a bot before its engine is considered unresponsive
+const DEFAULT_ENGINE_TIMEOUT = 30 * time.Second
+
+type TakebackPolicy string
+
+const (
+	TAKEBACK_ALWAYS TakebackPolicy = "always"
+	TAKEBACK_NEVER  TakebackPolicy = "never"
+	// TAKEBACK_MISTAKES accepts takebacks of moves evaluated as mistake or blunder only
+	TAKEBACK_MISTAKES TakebackPolicy = "mistakes"
+)
+
+var ErrEngineTimeout = errors.New("engine did not answer in time")
+var ErrNoMove = errors.New("engine did not return a move")
+
 type BotOptions struct {
 	Name       string   `yaml:"name" json:"name"`
 	Engine     string   `yaml:"engine" json:"-"`
 	Path       string   `yaml:"-" json:"-"`
 	Depth      int      `yaml:"depth" json:"-"`
 	MoveTimeMs int      `yaml:"moveTimeMs" json:"-"`
+	TimeoutMs  int      `yaml:"timeoutMs" json:"-"`
 	Threads    int      `yaml:"threads" json:"-"`
 	Options    []string `yaml:"options" json:"-"`
+	// Takebacks is the policy for takeback requests of the opponent. Takebacks are always accepted by default.
+	Takebacks TakebackPolicy `yaml:"takebacks" json:"-"`
 }
 
 type UCI struct {
-	engine *uci.Engine
-	depth  int
-	ms     int
-	name   string
+	engine  *uci.Engine
+	options BotOptions
+	depth   int
+	ms      int
+	timeout time.Duration
+	name    string
+	// hanging is set if the engine did not answer and may block any further command
+	hanging bool
 }
 
 func (p *UCI) IsBot() bool {
@@ -34,33 +59,138 @@ func (p *UCI) Name() string {
 	return p.name
 }
 
-func NewUCIPlayer(options BotOptions) *UCI {
+func NewUCIPlayer(options BotOptions) (*UCI, error) {
 	eng, err := util.CreateUCIEngine(options.Path, options.Options, options.Threads)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	log.Printf("UCI player created with name %s", options.Name)
 
+	timeout := time.Duration(options.MoveTimeMs)*time.Millisecond + DEFAULT_ENGINE_TIMEOUT
+	if options.TimeoutMs > 0 {
+		timeout = time.Duration(options.TimeoutMs) * time.Millisecond
+	}
+
 	return &UCI{
-		engine: eng,
-		depth:  options.Depth,
-		ms:     options.MoveTimeMs,
-		name:   options.Name,
+		engine:  eng,
+		options: options,
+		depth:   options.Depth,
+		ms:      options.MoveTimeMs,
+		timeout: timeout,
+		name:    options.Name,
+	}, nil
+}
+
+// MakeMove returns the best move of the engine. An unresponsive engine, e.g. after a crash, results in ErrEngineTimeout.
+func (p *UCI) MakeMove(ctx context.Context, game *chess.Game) (*chess.Move, error) {
+	return p.search(ctx, game, uci.CmdGo{MoveTime: time.Duration(p.ms) * time.Millisecond, Depth: p.depth}, p.timeout)
+}
+
+// MakeTimedMove lets the engine divide the time on the clock instead of using the configured move time
+func (p *UCI) MakeTimedMove(ctx context.Context, g *chess.Game, t game.SearchTime) (*chess.Move, error) {
+	cmd := uci.CmdGo{
+		WhiteTime:      t.White,
+		BlackTime:      t.Black,
+		WhiteIncrement: t.WhiteIncrement,
+		BlackIncrement: t.BlackIncrement,
+		MovesToGo:      t.MovesToGo,
+		Depth:          p.depth,
+	}
+
+	remaining := t.White
+	if g.Position().Turn() == chess.Black {
+		remaining = t.Black
 	}
+	return p.search(ctx, g, cmd, remaining+DEFAULT_ENGINE_TIMEOUT)
 }
 
-func (p *UCI) MakeMove(game *chess.Game) {
-	cmds := []uci.Cmd{uci.CmdPosition{Position: game.Position()}, uci.CmdGo{MoveTime: time.Duration(p.ms) * time.Millisecond, Depth: p.depth}}
+func (p *UCI) search(ctx context.Context, game *chess.Game, goCmd uci.CmdGo, timeout time.Duration) (*chess.Move, error) {
+	cmds := []uci.Cmd{uci.CmdPosition{Position: game.Position()}, goCmd}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.engine.Run(cmds...)
+	}()
 
-	if err := p.engine.Run(cmds...); err != nil {
-		log.Fatal(err)
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, err
+		}
+	case <-timeoutCtx.Done():
+		if ctx.Err() == nil {
+			p.hanging = true
+			return nil, ErrEngineTimeout
+		}
+
+		// stop the search and drop its result
+		p.engine.Run(uci.CmdStop)
+		<-done
+		return nil, ctx.Err()
 	}
+
 	move := p.engine.SearchResults().BestMove
-	if err := game.Move(move); err != nil {
-		log.Fatal(err)
+	if move == nil {
+		return nil, ErrNoMove
 	}
 
+	return move, nil
+}
+
+// Restart replaces the engine process with a new one
+func (p *UCI) Restart() error {
+	eng, err := util.CreateUCIEngine(p.options.Path, p.options.Options, p.options.Threads)
+	if err != nil {
+		return err
+	}
+
+	p.close()
+	p.engine = eng
+	p.hanging = false
+
+	log.Printf("Engine of UCI player %s restarted", p.name)
+	return nil
+}
+
+// AcceptTakeback decides about a takeback request according to the takeback policy of the bot
+func (p *UCI) AcceptTakeback(g *chess.Game, by chess.Color) bool {
+	switch p.options.Takebacks {
+	case TAKEBACK_NEVER:
+		return false
+	case TAKEBACK_MISTAKES:
+		return lastMoveWasMistake(g, by)
+	}
+
+	return true
+}
+
+// lastMoveWasMistake returns true if the last move of the given color was evaluated as mistake or blunder
+func lastMoveWasMistake(g *chess.Game, color chess.Color) bool {
+	positions := g.Positions()
+	comments := g.Comments()
+
+	for i := len(g.Moves()) - 1; i >= 0; i-- {
+		if positions[i].Turn() != color {
+			continue
+		}
+
+		if i >= len(comments) {
+			return false
+		}
+		for _, comment := range comments[i] {
+			switch game.EvalAccuracy(comment) {
+			case game.EVAL_ACC_MISTAKE, game.EVAL_ACC_BLUNDER:
+				return true
+			}
+		}
+		return false
+	}
+
+	return false
 }
 
 func (p *UCI) SetColor(color chess.Color) {
@@ -68,5 +198,14 @@ func (p *UCI) SetColor(color chess.Color) {
 }
 
 func (p *UCI) End() {
+	p.close()
+}
+
+func (p *UCI) close() {
+	if p.hanging {
+		// closing blocks as long as the hanging search holds the engine
+		go p.engine.Close()
+		return
+	}
 	p.engine.Close()
 }
@@ -0,0 +1,51 @@
+package player
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+const SYSFS_TTY = "/sys/class/tty"
+
+// FindUSBPort returns the serial port of the USB device with the given id, e.g. "045b:81b0" for vendor id 045b and
+// product id 81b0. The port is looked up in sysfs, so it is only found on Linux.
+func FindUSBPort(id string) (string, error) {
+	ids := strings.Split(strings.ToLower(id), ":")
+	if len(ids) != 2 {
+		return "", fmt.Errorf("invalid USB id %s, expected vendor:product", id)
+	}
+
+	ttys, err := filepath.Glob(filepath.Join(SYSFS_TTY, "tty*"))
+	if err != nil {
+		return "", err
+	}
+
+	for _, tty := range ttys {
+		device, err := filepath.EvalSymlinks(filepath.Join(tty, "device"))
+		if err != nil {
+			// no hardware device, e.g. a virtual terminal
+			continue
+		}
+
+		// the ids are stored in the USB device, which is a parent of the tty device
+		for dir := device; dir != "/" && dir != "."; dir = filepath.Dir(dir) {
+			vendor, err := ioutil.ReadFile(filepath.Join(dir, "idVendor"))
+			if err != nil {
+				continue
+			}
+			product, err := ioutil.ReadFile(filepath.Join(dir, "idProduct"))
+			if err != nil {
+				break
+			}
+
+			if strings.TrimSpace(string(vendor)) == ids[0] && strings.TrimSpace(string(product)) == ids[1] {
+				return filepath.Join("/dev", filepath.Base(tty)), nil
+			}
+			break
+		}
+	}
+
+	return "", fmt.Errorf("no serial port of USB device %s found", id)
+}
@@ -0,0 +1,371 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/notnil/chess"
+	"github.com/windler/chesspal/pkg/game"
+	"github.com/windler/chesspal/pkg/ui"
+)
+
+var ErrBoardInUse = errors.New("the board is already used by another game")
+var ErrGameInUse = errors.New("the game is already running in another session")
+
+// RESIGN_CONFIRM_TIME is the time to press the resign button of the clock a second time to confirm the resignation
+const RESIGN_CONFIRM_TIME = 3 * time.Second
+
+// Session is a game with its own UI. Only the session using the board receives moves from the board.
+type Session struct {
+	id          string
+	ui          *ui.WSUI
+	usesBoard   bool
+	mutex       *sync.Mutex
+	game        *game.Game
+	players     []game.Player
+	white       string
+	black       string
+	cancelSetup context.CancelFunc
+	// gameID is the id of the game played or resumed in the session
+	gameID string
+	// resignTimer runs while a resignation by the clock waits to be confirmed
+	resignTimer   *time.Timer
+	resignConfirm time.Duration
+}
+
+// Info describes a session for clients choosing a session to watch
+type Info struct {
+	ID        string `json:"id"`
+	White     string `json:"white"`
+	Black     string `json:"black"`
+	UsesBoard bool   `json:"usesBoard"`
+}
+
+func (s *Session) ID() string {
+	return s.id
+}
+
+func (s *Session) UI() *ui.WSUI {
+	return s.ui
+}
+
+func (s *Session) UsesBoard() bool {
+	return s.usesBoard
+}
+
+// SetPlayers sets the players of the session before the game is created
+func (s *Session) SetPlayers(white, black game.Player) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.players = []game.Player{white, black}
+	s.white = white.Name()
+	s.black = black.Name()
+}
+
+func (s *Session) Players() []game.Player {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.players
+}
+
+// Game returns the game of the session or nil while the session is set up
+func (s *Session) Game() *game.Game {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.game
+}
+
+func (s *Session) SetGame(g *game.Game) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.game = g
+}
+
+// SetupContext returns a context for setting up the session which is cancelled by Abort
+func (s *Session) SetupContext() (context.Context, context.CancelFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancelSetup = cancel
+	return ctx, cancel
+}
+
+// Abort aborts the setup or the game of the session
+func (s *Session) Abort(discard bool) error {
+	s.mutex.Lock()
+	cancelSetup, g := s.cancelSetup, s.game
+	s.mutex.Unlock()
+
+	if cancelSetup != nil {
+		cancelSetup()
+	}
+	if g == nil {
+		return nil
+	}
+
+	err := g.Abort(discard)
+	if err == game.ErrGameNotRunning {
+		return nil
+	}
+	return err
+}
+
+// RequestResign waits for a resignation to be confirmed by requesting it again. It returns true if a resignation was
+// already pending, which confirms it. Otherwise expired is called unless the resignation is confirmed or cancelled in
+// time.
+func (s *Session) RequestResign(expired func()) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.resignTimer != nil {
+		s.resignTimer.Stop()
+		s.resignTimer = nil
+		return true
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(s.resignConfirm, func() {
+		s.mutex.Lock()
+		current := s.resignTimer == timer
+		if current {
+			s.resignTimer = nil
+		}
+		s.mutex.Unlock()
+
+		if current {
+			expired()
+		}
+	})
+	s.resignTimer = timer
+	return false
+}
+
+// CancelResign withdraws a pending resignation and returns true if there was one
+func (s *Session) CancelResign() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.resignTimer == nil {
+		return false
+	}
+	s.resignTimer.Stop()
+	s.resignTimer = nil
+	return true
+}
+
+func (s *Session) Info() Info {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return Info{
+		ID:        s.id,
+		White:     s.white,
+		Black:     s.black,
+		UsesBoard: s.usesBoard,
+	}
+}
+
+// Manager keeps track of all running sessions, the clients watching them and the board
+type Manager struct {
+	mutex        *sync.Mutex
+	sessions     map[string]*Session
+	clients      map[*ui.Client]*Session
+	lobby        *ui.WSUI
+	boardSession *Session
+	board        chess.Board
+	nextID       int
+}
+
+// NewManager creates a manager. Clients not watching a session are added to the lobby UI.
+func NewManager(lobby *ui.WSUI) *Manager {
+	return &Manager{
+		mutex:    &sync.Mutex{},
+		sessions: map[string]*Session{},
+		clients:  map[*ui.Client]*Session{},
+		lobby:    lobby,
+	}
+}
+
+// Create creates a new session. Only one session can use the board at a time.
+func (m *Manager) Create(usesBoard bool) (*Session, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if usesBoard && m.boardSession != nil {
+		return nil, ErrBoardInUse
+	}
+
+	m.nextID++
+	s := &Session{
+		id:            fmt.Sprintf("session-%d", m.nextID),
+		ui:            ui.NewWS(),
+		usesBoard:     usesBoard,
+		mutex:         &sync.Mutex{},
+		resignConfirm: RESIGN_CONFIRM_TIME,
+	}
+
+	m.sessions[s.id] = s
+	if usesBoard {
+		m.boardSession = s
+	}
+
+	return s, nil
+}
+
+// Get returns the running session with the given id or nil
+func (m *Manager) Get(id string) *Session {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.sessions[id]
+}
+
+// Sessions returns infos about all running sessions
+func (m *Manager) Sessions() []Info {
+	m.mutex.Lock()
+	sessions := []*Session{}
+	for _, s := range m.sessions {
+		sessions = append(sessions, s)
+	}
+	m.mutex.Unlock()
+
+	infos := []Info{}
+	for _, s := range sessions {
+		infos = append(infos, s.Info())
+	}
+	return infos
+}
+
+// End removes a session and releases the board. Its clients keep showing the final state and return to the lobby.
+func (m *Manager) End(s *Session) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.sessions, s.id)
+	if m.boardSession == s {
+		m.boardSession = nil
+	}
+
+	for c, subscribed := range m.clients {
+		if subscribed == s {
+			s.ui.RemoveClient(c)
+			m.lobby.AdoptClient(c)
+			m.clients[c] = nil
+		}
+	}
+}
+
+// ClaimGame assigns the id of the game played or resumed in a session. A game can only run in one session.
+func (m *Manager) ClaimGame(s *Session, id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, other := range m.sessions {
+		if other != s && other.gameID == id {
+			return ErrGameInUse
+		}
+	}
+	s.gameID = id
+
+	return nil
+}
+
+// RunningGame returns true if the game with the given id is played or resumed in a session
+func (m *Manager) RunningGame(id string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, s := range m.sessions {
+		if s.gameID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// BoardSession returns the session using the board or nil
+func (m *Manager) BoardSession() *Session {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.boardSession
+}
+
+// Connect adds a new client to the lobby
+func (m *Manager) Connect(c *ui.Client) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.clients[c] = nil
+	m.lobby.AddClient(c)
+}
+
+func (m *Manager) Disconnect(c *ui.Client) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if s := m.clients[c]; s != nil {
+		s.ui.RemoveClient(c)
+	}
+	m.lobby.RemoveClient(c)
+	delete(m.clients, c)
+}
+
+// Subscribe lets a client watch a session instead of the lobby or another session
+func (m *Manager) Subscribe(c *ui.Client, s *Session) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if previous := m.clients[c]; previous != nil {
+		previous.ui.RemoveClient(c)
+	}
+	m.lobby.RemoveClient(c)
+
+	m.clients[c] = s
+	s.ui.AddClient(c)
+}
+
+// Broadcast sends a message to all connected clients
+func (m *Manager) Broadcast(msg interface{}) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for c := range m.clients {
+		go c.Send(msg)
+	}
+}
+
+// Subscription returns the session watched by a client or nil
+func (m *Manager) Subscription(c *ui.Client) *Session {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.clients[c]
+}
+
+// SetBoard stores the last position read from the board and shows it in the lobby while the board is not used
+func (m *Manager) SetBoard(board chess.Board) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.board = board
+	if m.boardSession == nil {
+		m.lobby.SendBoard(board)
+	}
+}
+
+// Board returns the last position read from the board
+func (m *Manager) Board() chess.Board {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.board
+}
@@ -0,0 +1,77 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/windler/chesspal/pkg/ui"
+)
+
+func TestClaimGame(t *testing.T) {
+	m := NewManager(ui.NewWS())
+	first, _ := m.Create(false)
+	second, _ := m.Create(false)
+
+	if err := m.ClaimGame(first, "game1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.ClaimGame(second, "game1"); err != ErrGameInUse {
+		t.Errorf("expected ErrGameInUse, got %v", err)
+	}
+	if !m.RunningGame("game1") || m.RunningGame("game2") {
+		t.Error("only game1 should be running")
+	}
+
+	m.End(first)
+	if m.RunningGame("game1") {
+		t.Error("game1 should not be running after its session ended")
+	}
+	if err := m.ClaimGame(second, "game1"); err != nil {
+		t.Errorf("expected the game to be claimed after its session ended, got %v", err)
+	}
+}
+
+func TestRequestResign(t *testing.T) {
+	m := NewManager(ui.NewWS())
+	s, _ := m.Create(true)
+	s.resignConfirm = 20 * time.Millisecond
+	expired := make(chan bool, 4)
+	expire := func() {
+		expired <- true
+	}
+
+	// the second request confirms the resignation and stops the timer
+	if s.RequestResign(expire) {
+		t.Fatal("the first request must not resign")
+	}
+	if !s.RequestResign(expire) {
+		t.Fatal("the second request must confirm the resignation")
+	}
+
+	// another button cancels the pending resignation
+	s.RequestResign(expire)
+	if !s.CancelResign() {
+		t.Error("expected a pending resignation to be cancelled")
+	}
+	if s.CancelResign() {
+		t.Error("no resignation must be pending after it was cancelled")
+	}
+
+	select {
+	case <-expired:
+		t.Fatal("confirmed and cancelled resignations must not expire")
+	case <-time.After(3 * s.resignConfirm):
+	}
+
+	// an unconfirmed resignation expires once and is no longer pending
+	s.RequestResign(expire)
+	select {
+	case <-expired:
+	case <-time.After(time.Second):
+		t.Fatal("expected the resignation to expire")
+	}
+	if s.RequestResign(expire) {
+		t.Error("an expired resignation must not be confirmed")
+	}
+	s.CancelResign()
+}
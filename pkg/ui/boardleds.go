@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"log"
+	"sync"
+
+	"github.com/notnil/chess"
+	"github.com/windler/chesspal/pkg/game"
+	"github.com/windler/chesspal/pkg/player"
+)
+
+// BoardLeds lights the squares of bot moves on boards with LEDs until the move is made on the board
+type BoardLeds struct {
+	engine player.ElectronicBoard
+	mutex  *sync.Mutex
+	lit    bool
+}
+
+func NewBoardLeds(engine player.ElectronicBoard) *BoardLeds {
+	return &BoardLeds{
+		engine: engine,
+		mutex:  &sync.Mutex{},
+	}
+}
+
+func (l *BoardLeds) Render(g chess.Game, action game.UIAction) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	switch {
+	case action.Event == game.EVENT_MOVE && action.Execution != nil:
+		if err := l.engine.ShowMove(action.Execution.Move); err != nil {
+			log.Printf("error lighting LEDs: %v", err)
+		}
+		l.lit = true
+
+	case action.Execution == nil && l.lit:
+		if err := l.engine.ClearLEDs(); err != nil {
+			log.Printf("error turning off LEDs: %v", err)
+		}
+		l.lit = false
+	}
+}
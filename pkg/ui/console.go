@@ -15,6 +15,7 @@ type Console struct {
 	lastEvaluation game.EvalResult
 	lastResult     string
 	lastStatus     string
+	lastClock      string
 	writer         *uilive.Writer
 	mutex          *sync.Mutex
 }
@@ -47,6 +48,14 @@ func (c *Console) Render(g chess.Game, action game.UIAction) {
 	}
 	if g.Outcome() != chess.NoOutcome {
 		c.lastResult = fmt.Sprintf("result: %s\n", g.Outcome())
+	} else if action.Status == game.STATUS_PAUSED || action.Status == game.STATUS_ABORTED {
+		c.lastResult = fmt.Sprintf("%s\n", action.Status)
+	} else {
+		c.lastResult = ""
+	}
+
+	if action.Clock != nil {
+		c.lastClock = fmt.Sprintf("white: %s black: %s\n", game.FormatClock(action.Clock.White), game.FormatClock(action.Clock.Black))
 	}
 
 	if action.Evaluation != nil {
@@ -62,12 +71,12 @@ func (c *Console) Render(g chess.Game, action game.UIAction) {
 		// 	acc = color.YellowString(fmt.Sprintf("%v", c.lastEvaluation.Accuracy))
 		// }
 		if c.lastEvaluation.IsForcedMate {
-			fmt.Fprintf(c.writer, "%s\nForced mate in %d\n%s%s\n", c.lastPosition, action.Evaluation.ForcedMateIn, c.lastStatus, c.lastResult)
+			fmt.Fprintf(c.writer, "%s\nForced mate in %d\n%s%s%s\n", c.lastPosition, action.Evaluation.ForcedMateIn, c.lastClock, c.lastStatus, c.lastResult)
 		} else {
-			fmt.Fprintf(c.writer, "%s\nEvaluation: %.2f\n%v\n\n%s%s\n", c.lastPosition, action.Evaluation.Pawn, acc, c.lastStatus, c.lastResult)
+			fmt.Fprintf(c.writer, "%s\nEvaluation: %.2f\n%v\n\n%s%s%s\n", c.lastPosition, action.Evaluation.Pawn, acc, c.lastClock, c.lastStatus, c.lastResult)
 		}
 	} else {
-		fmt.Fprintf(c.writer, "%s\n%s%s\n", c.lastPosition, c.lastStatus, c.lastResult)
+		fmt.Fprintf(c.writer, "%s\n%s%s%s\n", c.lastPosition, c.lastClock, c.lastStatus, c.lastResult)
 	}
 
 	c.writer.Stop()
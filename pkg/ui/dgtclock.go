@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/notnil/chess"
+	"github.com/windler/chesspal/pkg/game"
+	"github.com/windler/chesspal/pkg/player"
+)
+
+// DGTClock shows the game clock, bot moves and evaluations on a DGT 3000 connected to the board
+type DGTClock struct {
+	engine     player.ElectronicBoard
+	upsideDown bool
+	mutex      *sync.Mutex
+	// showsMove is true while a bot move is displayed that still has to be made on the board
+	showsMove bool
+}
+
+// NewDGTClock creates a clock UI. White's time is shown on the left side unless the board is upside down.
+func NewDGTClock(engine player.ElectronicBoard, upsideDown bool) *DGTClock {
+	return &DGTClock{
+		engine:     engine,
+		upsideDown: upsideDown,
+		mutex:      &sync.Mutex{},
+	}
+}
+
+func (c *DGTClock) Render(g chess.Game, action game.UIAction) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if action.Clock != nil && (!c.showsMove || action.Event == game.EVENT_MOVE) {
+		// the times replace the displayed text
+		c.check(c.setAndRun(*action.Clock))
+	}
+
+	switch {
+	case action.Event == game.EVENT_MOVE && action.Execution != nil:
+		positions := g.Positions()
+		move := chess.AlgebraicNotation{}.Encode(positions[len(positions)-2], action.Execution.Move)
+		c.check(c.engine.ClockText(move, true))
+		c.showsMove = true
+
+	case action.Execution != nil && action.Execution.Mistake != "":
+		c.check(c.engine.ClockText("mistake", true))
+
+	case action.Event == game.EVENT_DRAW_OFFER && !c.showsMove:
+		c.check(c.engine.ClockText("draw?", true))
+
+	case action.Event == game.EVENT_EVALUATION && action.Evaluation != nil && !c.showsMove:
+		c.check(c.engine.ClockText(evaluationText(*action.Evaluation), false))
+
+	case action.Execution == nil && c.showsMove:
+		c.check(c.engine.ClockEnd())
+		c.showsMove = false
+	}
+}
+
+func (c *DGTClock) setAndRun(clock game.ClockState) error {
+	left, right := clock.White, clock.Black
+	leftColor := chess.White
+	if c.upsideDown {
+		left, right = right, left
+		leftColor = chess.Black
+	}
+
+	running := player.CLOCK_SIDE_NONE
+	switch clock.Running {
+	case leftColor:
+		running = player.CLOCK_SIDE_LEFT
+	case leftColor.Other():
+		running = player.CLOCK_SIDE_RIGHT
+	}
+
+	return c.engine.SetClock(left, right, running)
+}
+
+func (c *DGTClock) check(err error) {
+	if err != nil {
+		log.Printf("error sending to clock: %v", err)
+	}
+}
+
+func evaluationText(eval game.EvalResult) string {
+	if eval.IsForcedMate {
+		return fmt.Sprintf("M%d", eval.ForcedMateIn)
+	}
+	return fmt.Sprintf("%+.2f", eval.Pawn)
+}
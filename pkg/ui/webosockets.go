@@ -14,41 +14,124 @@ import (
 	"github.com/windler/chesspal/pkg/util"
 )
 
+// Client is a websocket connection that can be written to from several goroutines
+type Client struct {
+	ws    *websocket.Conn
+	mutex *sync.Mutex
+}
+
+func NewClient(ws *websocket.Conn) *Client {
+	return &Client{
+		ws:    ws,
+		mutex: &sync.Mutex{},
+	}
+}
+
+func (c *Client) Send(msg interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.ws.SetWriteDeadline(time.Now().Add(time.Second * 5))
+	if err := c.ws.WriteJSON(msg); !errors.Is(err, nil) {
+		log.Printf("error occurred: %v", err)
+	}
+}
+
 type WSUI struct {
-	sockets      map[*websocket.Conn]*sync.Mutex
+	clients      map[*Client]bool
 	mutex        *sync.Mutex
 	currentState *GameState
+	// syncSquares are the squares of the physical board that differ from the game
+	syncSquares []chess.Square
 }
 
 func NewWS() *WSUI {
 	game := chess.NewGame()
 	return &WSUI{
 		mutex:   &sync.Mutex{},
-		sockets: make(map[*websocket.Conn]*sync.Mutex),
+		clients: make(map[*Client]bool),
 		currentState: &GameState{
 			SVGPosition: util.GetSVG(*game.Position().Board()),
 		},
 	}
 }
 
-func (u *WSUI) AddWebsocket(ws *websocket.Conn) {
-	u.sockets[ws] = &sync.Mutex{}
-	u.sendCurentState(ws, u.sockets[ws])
+// AddClient adds a client and sends the current state to it
+func (u *WSUI) AddClient(c *Client) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	u.clients[c] = true
+	state := *u.currentState
+	go c.Send(&state)
+}
+
+// AdoptClient adds a client without sending the current state, e.g. to keep showing a finished game
+func (u *WSUI) AdoptClient(c *Client) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	u.clients[c] = true
+}
+
+func (u *WSUI) RemoveClient(c *Client) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	delete(u.clients, c)
 }
 
-func (u *WSUI) RemoveWebsocket(ws *websocket.Conn) {
-	delete(u.sockets, ws)
+// Clients returns all clients of the UI
+func (u *WSUI) Clients() []*Client {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	clients := []*Client{}
+	for c := range u.clients {
+		clients = append(clients, c)
+	}
+	return clients
 }
 
 type GameState struct {
-	SVGPosition     string  `json:"svgPosition"`
-	SVGNextBestMove string  `json:"svgNextBestMove"`
-	Pawn            float64 `json:"pawn"`
-	Moves           []Move  `json:"moves"`
-	Turn            string  `json:"turn"`
-	PGN             string  `json:"pgn"`
-	FEN             string  `json:"fen"`
-	Outcome         string  `json:"outcome"`
+	SVGPosition     string       `json:"svgPosition"`
+	SVGNextBestMove string       `json:"svgNextBestMove"`
+	Pawn            float64      `json:"pawn"`
+	Moves           []Move       `json:"moves"`
+	Turn            string       `json:"turn"`
+	PGN             string       `json:"pgn"`
+	FEN             string       `json:"fen"`
+	Outcome         string       `json:"outcome"`
+	WhiteTimeMs     int64        `json:"whiteTimeMs"`
+	BlackTimeMs     int64        `json:"blackTimeMs"`
+	ClockRunning    string       `json:"clockRunning"`
+	SyncDiff        []SquareDiff `json:"syncDiff"`
+	Method          string       `json:"method"`
+	DrawClaims      []string     `json:"drawClaims"`
+	Status          string       `json:"status"`
+	// TakebackRequest is the color requesting a takeback
+	TakebackRequest string `json:"takebackRequest"`
+	TakebacksLeft   int    `json:"takebacksLeft"`
+	// DrawOffer is the color offering a draw
+	DrawOffer string `json:"drawOffer"`
+	// Instruction tells how to make the bot move on the board
+	Instruction string `json:"instruction"`
+	// Mistake is a move made on the board before the bot move was executed
+	Mistake string `json:"mistake"`
+}
+
+type SquareDiff struct {
+	Square   string `json:"square"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+func newSquareDiff(d game.SquareDiff) SquareDiff {
+	return SquareDiff{
+		Square:   d.Square.String(),
+		Expected: game.PieceString(d.Expected),
+		Actual:   game.PieceString(d.Actual),
+	}
 }
 
 type Move struct {
@@ -59,19 +142,14 @@ type Move struct {
 
 var yellow = color.RGBA{255, 255, 0, 1}
 var green = color.RGBA{0, 90, 0, 1}
+var red = color.RGBA{200, 0, 0, 1}
 
 var moveEncoder = chess.AlgebraicNotation{}
 
 func (u *WSUI) Render(g chess.Game, action game.UIAction) {
 	u.mutex.Lock()
-	if len(g.Moves()) == 0 {
-		u.currentState.SVGPosition = util.GetSVG(*g.Position().Board())
-	} else {
-		move := g.Moves()[len(g.Moves())-1]
-		mark := image.MarkSquares(yellow, move.S1(), move.S2())
-
-		u.currentState.SVGPosition = util.GetSVG(*g.Position().Board(), mark)
-
+	u.currentState.SVGPosition = u.positionSVG(g)
+	if len(g.Moves()) > 0 {
 		u.currentState.Turn = g.Position().Turn().String()
 		u.currentState.PGN = g.String()
 	}
@@ -83,8 +161,13 @@ func (u *WSUI) Render(g chess.Game, action game.UIAction) {
 		moveEncoded := moveEncoder.Encode(pos, m)
 
 		accuracy := ""
-		if len(g.Comments()) >= moveIndex+1 && len(g.Comments()[moveIndex]) > 0 {
-			accuracy = g.Comments()[moveIndex][0]
+		if len(g.Comments()) >= moveIndex+1 {
+			for _, comment := range g.Comments()[moveIndex] {
+				switch game.EvalAccuracy(comment) {
+				case game.EVAL_ACC_INACCURATE, game.EVAL_ACC_MISTAKE, game.EVAL_ACC_BLUNDER:
+					accuracy = comment
+				}
+			}
 		}
 
 		moves = append(moves, Move{
@@ -116,6 +199,15 @@ func (u *WSUI) Render(g chess.Game, action game.UIAction) {
 		}
 	}
 
+	if action.Clock != nil {
+		u.currentState.WhiteTimeMs = action.Clock.White.Milliseconds()
+		u.currentState.BlackTimeMs = action.Clock.Black.Milliseconds()
+		u.currentState.ClockRunning = ""
+		if action.Clock.Running != chess.NoColor {
+			u.currentState.ClockRunning = action.Clock.Running.String()
+		}
+	}
+
 	switch g.Outcome() {
 	case chess.BlackWon:
 		u.currentState.Pawn = 0
@@ -128,32 +220,121 @@ func (u *WSUI) Render(g chess.Game, action game.UIAction) {
 	u.currentState.FEN = g.Position().Board().String()
 
 	u.currentState.Outcome = g.Outcome().String()
+	u.currentState.Status = string(action.Status)
+
+	u.currentState.TakebackRequest = ""
+	if action.Takeback != nil {
+		u.currentState.TakebackRequest = action.Takeback.By.String()
+	}
+	u.currentState.TakebacksLeft = action.TakebacksLeft
+
+	u.currentState.DrawOffer = ""
+	if action.DrawOffer != nil {
+		u.currentState.DrawOffer = action.DrawOffer.By.String()
+	}
+
+	u.currentState.Instruction = ""
+	u.currentState.Mistake = ""
+	if action.Execution != nil {
+		u.currentState.Instruction = action.Execution.Instruction
+		u.currentState.Mistake = action.Execution.Mistake
+	}
 
-	for ws, mutex := range u.sockets {
-		go u.sendCurentState(ws, mutex)
+	u.currentState.Method = ""
+	if g.Method() != chess.NoMethod {
+		u.currentState.Method = g.Method().String()
 	}
+
+	u.currentState.DrawClaims = []string{}
+	for _, method := range game.ClaimableDraws(&g) {
+		u.currentState.DrawClaims = append(u.currentState.DrawClaims, method.String())
+	}
+
+	u.sendCurrentState()
 	u.mutex.Unlock()
 }
 
-func (u *WSUI) sendCurentState(ws *websocket.Conn, mutex *sync.Mutex) {
-	mutex.Lock()
-	ws.SetWriteDeadline(time.Now().Add(time.Second * 5))
+// RenderSync marks the squares that have to be fixed on the physical board
+func (u *WSUI) RenderSync(g chess.Game, sync game.BoardSync) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	u.syncSquares = []chess.Square{}
+	u.currentState.SyncDiff = []SquareDiff{}
+	for _, d := range sync.Diff {
+		u.syncSquares = append(u.syncSquares, d.Square)
+		u.currentState.SyncDiff = append(u.currentState.SyncDiff, newSquareDiff(d))
+	}
+
+	u.currentState.SVGPosition = u.positionSVG(g)
+	u.sendCurrentState()
+}
+
+// positionSVG renders the position marking the last move and the squares out of sync. The mutex must be held by the caller.
+func (u *WSUI) positionSVG(g chess.Game) string {
+	marks := []func(*image.Encoder){}
+	if len(g.Moves()) > 0 {
+		move := g.Moves()[len(g.Moves())-1]
+		marks = append(marks, image.MarkSquares(yellow, move.S1(), move.S2()))
+	}
+	if len(u.syncSquares) > 0 {
+		marks = append(marks, image.MarkSquares(red, u.syncSquares...))
+	}
 
-	if err := ws.WriteJSON(u.currentState); !errors.Is(err, nil) {
-		log.Printf("error occurred: %v", err)
+	return util.GetSVG(*g.Position().Board(), marks...)
+}
+
+// sendCurrentState sends the current state to all clients. The mutex must be held by the caller.
+func (u *WSUI) sendCurrentState() {
+	state := *u.currentState
+	for c := range u.clients {
+		go c.Send(&state)
 	}
-	mutex.Unlock()
 }
 
 func (u *WSUI) Reset() {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
 	u.currentState = &GameState{}
+	u.syncSquares = nil
 }
 
 func (u *WSUI) SendBoard(board chess.Board) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
 	u.currentState = &GameState{
 		SVGPosition: util.GetSVG(board),
 	}
-	for ws, mutex := range u.sockets {
-		go u.sendCurentState(ws, mutex)
+	u.sendCurrentState()
+}
+
+// SendBoardDiff shows the expected board and marks all squares that differ from the physical board
+func (u *WSUI) SendBoardDiff(expected chess.Board, diff []game.SquareDiff) {
+	squares := []chess.Square{}
+	syncDiff := []SquareDiff{}
+	for _, d := range diff {
+		squares = append(squares, d.Square)
+		syncDiff = append(syncDiff, newSquareDiff(d))
+	}
+
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	u.currentState = &GameState{
+		SVGPosition: util.GetSVG(expected, image.MarkSquares(red, squares...)),
+		SyncDiff:    syncDiff,
+	}
+	u.sendCurrentState()
+}
+
+// Send sends a message to all clients
+func (u *WSUI) Send(msg interface{}) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	for c := range u.clients {
+		go c.Send(msg)
 	}
 }
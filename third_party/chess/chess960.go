@@ -0,0 +1,189 @@
+package chess
+
+import "unicode"
+
+// Chess960 castling: castle rights may be given in Shredder-FEN (the files of the castling rooks, e.g. HAha) or
+// X-FEN (KQkq for the outermost rooks). The king always ends on the g or c file and the rook on the f or d file.
+// Castling moves are encoded as king to rook square (e.g. e1h1) unless the position has standard castle rights and
+// the king and rook stand on their standard squares, in which case the standard encoding (e.g. e1g1) is used.
+
+// castleRookSquare returns the square of the rook the color may castle with on the given side or NoSquare
+func (pos *Position) castleRookSquare(c Color, side Side) Square {
+	kingSq, rank := pos.board.whiteKingSq, Rank1
+	if c == Black {
+		kingSq, rank = pos.board.blackKingSq, Rank8
+	}
+	if kingSq == NoSquare || kingSq.Rank() != rank {
+		return NoSquare
+	}
+	rook := getPiece(Rook, c)
+
+	for _, r := range string(pos.castleRights) {
+		if (c == White) != unicode.IsUpper(r) {
+			continue
+		}
+
+		switch unicode.ToLower(r) {
+		case 'k':
+			if side != KingSide {
+				continue
+			}
+			// the outermost rook
+			for f := FileH; f > kingSq.File(); f-- {
+				if sq := NewSquare(f, rank); pos.board.Piece(sq) == rook {
+					return sq
+				}
+			}
+		case 'q':
+			if side != QueenSide {
+				continue
+			}
+			for f := FileA; f < kingSq.File(); f++ {
+				if sq := NewSquare(f, rank); pos.board.Piece(sq) == rook {
+					return sq
+				}
+			}
+		case 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h':
+			f := File(unicode.ToLower(r) - 'a')
+			if (side == KingSide) != (f > kingSq.File()) {
+				continue
+			}
+			if sq := NewSquare(f, rank); pos.board.Piece(sq) == rook {
+				return sq
+			}
+		}
+	}
+	return NoSquare
+}
+
+// castleRightSquare returns the rook square of a castle right or NoSquare
+func (pos *Position) castleRightSquare(r rune) Square {
+	c := White
+	if !unicode.IsUpper(r) {
+		c = Black
+	}
+
+	kingSq := pos.board.whiteKingSq
+	if c == Black {
+		kingSq = pos.board.blackKingSq
+	}
+	if kingSq == NoSquare {
+		return NoSquare
+	}
+
+	switch unicode.ToLower(r) {
+	case 'k':
+		return pos.castleRookSquare(c, KingSide)
+	case 'q':
+		return pos.castleRookSquare(c, QueenSide)
+	}
+	if File(unicode.ToLower(r)-'a') > kingSq.File() {
+		return pos.castleRookSquare(c, KingSide)
+	}
+	return pos.castleRookSquare(c, QueenSide)
+}
+
+// isStandardCastle returns true if the standard encoding is used for castling with the king and rook
+func (pos *Position) isStandardCastle(kingSq, rookSq Square) bool {
+	for _, r := range string(pos.castleRights) {
+		if r != '-' && unicode.ToLower(r) != 'k' && unicode.ToLower(r) != 'q' {
+			return false
+		}
+	}
+	return kingSq.File() == FileE && (rookSq.File() == FileA || rookSq.File() == FileH)
+}
+
+// castleTargets returns the squares the king and rook get by castling to the side
+func castleTargets(c Color, side Side) (Square, Square) {
+	rank := Rank1
+	if c == Black {
+		rank = Rank8
+	}
+	if side == KingSide {
+		return NewSquare(FileG, rank), NewSquare(FileF, rank)
+	}
+	return NewSquare(FileC, rank), NewSquare(FileD, rank)
+}
+
+// castleMove returns the castling move to the side or nil if castling is not possible
+func castleMove(pos *Position, side Side) *Move {
+	c := pos.turn
+	rookSq := pos.castleRookSquare(c, side)
+	if rookSq == NoSquare || pos.inCheck {
+		return nil
+	}
+	kingSq := pos.board.whiteKingSq
+	if c == Black {
+		kingSq = pos.board.blackKingSq
+	}
+	kingTo, rookTo := castleTargets(c, side)
+
+	// every square the king or rook passes has to be empty except the king and rook themselves
+	for _, path := range [][2]Square{{kingSq, kingTo}, {rookSq, rookTo}} {
+		for _, sq := range squaresBetween(path[0], path[1]) {
+			if sq != kingSq && sq != rookSq && pos.board.isOccupied(sq) {
+				return nil
+			}
+		}
+	}
+
+	// no square the king passes may be attacked, the king and rook can not block an attack as they move as well
+	cp := pos.copy()
+	for _, p := range []Piece{getPiece(King, c), getPiece(Rook, c)} {
+		cp.board.setBBForPiece(p, cp.board.bbForPiece(p)&^(bbForSquare(kingSq)|bbForSquare(rookSq)))
+	}
+	cp.board.calcConvienceBBs(nil)
+	if squaresAreAttacked(cp, squaresBetween(kingSq, kingTo)...) {
+		return nil
+	}
+
+	m := &Move{s1: kingSq, s2: rookSq}
+	if pos.isStandardCastle(kingSq, rookSq) {
+		m.s2 = kingTo
+	}
+	if side == KingSide {
+		m.addTag(KingSideCastle)
+	} else {
+		m.addTag(QueenSideCastle)
+	}
+	addTags(m, pos)
+	return m
+}
+
+// squaresBetween returns the squares of a rank from s1 to s2 including both
+func squaresBetween(s1, s2 Square) []Square {
+	from, to := s1.File(), s2.File()
+	if from > to {
+		from, to = to, from
+	}
+
+	sqs := []Square{}
+	for f := from; f <= to; f++ {
+		sqs = append(sqs, NewSquare(f, s1.Rank()))
+	}
+	return sqs
+}
+
+// castle moves the king and rook of a castling move
+func (b *Board) castle(m *Move) {
+	king := b.Piece(m.s1)
+	rook := getPiece(Rook, king.Color())
+	side := KingSide
+	if m.HasTag(QueenSideCastle) {
+		side = QueenSide
+	}
+
+	rookSq := m.s2
+	if b.Piece(rookSq) != rook {
+		// standard encoding, the rook is in the corner
+		rookSq = NewSquare(FileH, m.s1.Rank())
+		if side == QueenSide {
+			rookSq = NewSquare(FileA, m.s1.Rank())
+		}
+	}
+	kingTo, rookTo := castleTargets(king.Color(), side)
+
+	b.setBBForPiece(king, b.bbForPiece(king)&^bbForSquare(m.s1)|bbForSquare(kingTo))
+	b.setBBForPiece(rook, b.bbForPiece(rook)&^bbForSquare(rookSq)|bbForSquare(rookTo))
+	b.calcConvienceBBs(nil)
+}
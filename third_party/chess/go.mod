@@ -0,0 +1,5 @@
+module github.com/notnil/chess
+
+go 1.14
+
+require github.com/ajstarks/svgo v0.0.0-20200320125537-f189e35d30ca